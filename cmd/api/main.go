@@ -2,18 +2,27 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/tmozzze/SQL_Converter/database/migrations"
 	_ "github.com/tmozzze/SQL_Converter/docs"
 	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 	"github.com/tmozzze/SQL_Converter/internal/http/handler"
 	"github.com/tmozzze/SQL_Converter/internal/repository/postgres"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlite"
 	"github.com/tmozzze/SQL_Converter/internal/service"
+	"github.com/tmozzze/SQL_Converter/internal/watcher"
 	"github.com/tmozzze/SQL_Converter/pkg/database"
 )
 
@@ -40,23 +49,43 @@ func main() {
 	log.Info("starting SQL_Converter", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
-	// Init DB
-	db, err := database.NewPostgresDB(cfg.Postgres)
+	// Init DB and migrations (service-owned tables)
+	db, migrator, repo, err := openStorage(cfg, log)
 	if err != nil {
 		log.Error("failed to init database", slog.Any("err", err))
 		os.Exit(1)
 	}
 	defer db.Close()
-	log.Info("connect to DB")
+	log.Info("connect to DB", slog.String("dialect", cfg.DBDialect))
 
-	// Init Repos
-	repo := postgres.NewRepository(db, log)
+	// Subcommand: migrate up/down/status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Error("migrate failed", slog.Any("err", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := migrator.Up(context.Background()); err != nil {
+		log.Error("failed to apply migrations", slog.Any("err", err))
+		os.Exit(1)
+	}
 
 	// Init Service
-	svc := service.NewService(repo, log)
+	svc := service.NewService(repo, cfg.Analyzer, cfg.Import, log)
+
+	// Subcommand: watch (hot-folder ingestion without HTTP server)
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		if err := runWatch(svc, cfg.Watcher, log); err != nil {
+			log.Error("watch failed", slog.Any("err", err))
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Init Handler
-	handler := handler.NewHandler(svc, log)
+	handler := handler.NewHandler(svc, cfg.HTTPServer.MaxUploadSize, log)
 
 	// Init Router
 	mux := http.NewServeMux()
@@ -96,6 +125,83 @@ func main() {
 	log.Info("server exited properly")
 }
 
+// openStorage - open DB of configured dialect with its migrator and repository
+func openStorage(cfg *config.Config, log *slog.Logger) (*sql.DB, *database.Migrator, domain.Repository, error) {
+	switch models.SQLDialect(cfg.DBDialect) {
+	case models.DialectPostgres:
+		db, err := database.NewPostgresDB(cfg.Postgres)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		migrator := database.NewMigrator(db, migrationsFS(cfg.MigrationsDir, migrations.FS, log), log)
+		return db, migrator, postgres.NewRepository(db, log), nil
+	case models.DialectSQLite:
+		db, err := database.NewSQLiteDB(cfg.SQLite)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		dir := filepath.Join(cfg.MigrationsDir, "sqlite")
+		migrator := database.NewSQLiteMigrator(db, migrationsFS(dir, migrations.SQLite(), log), log)
+		return db, migrator, sqlite.NewRepository(db, log), nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported db dialect %q (use postgres or sqlite)", cfg.DBDialect)
+	}
+}
+
+// migrationsFS - return migrations directory from config if it exists, otherwise embedded migrations
+func migrationsFS(dir string, embedded fs.FS, log *slog.Logger) fs.FS {
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		log.Info("using migrations from directory", slog.String("dir", dir))
+		return os.DirFS(dir)
+	}
+	return embedded
+}
+
+// runMigrate - run migrate subcommand (up, down, status)
+func runMigrate(migrator *database.Migrator, args []string) error {
+	ctx := context.Background()
+
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%05d_%s\t%s\n", st.Version, st.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down or status)", cmd)
+	}
+}
+
+// runWatch - import files dropped to configured directories until SIGINT/SIGTERM
+func runWatch(svc domain.Service, cfg config.WatcherCfg, log *slog.Logger) error {
+	w, err := watcher.NewWatcher(svc.Processor(), cfg, log)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return w.Run(ctx)
+}
+
 func setupLogger(env string) *slog.Logger {
 	switch env {
 	case envLocal: // Text Debug
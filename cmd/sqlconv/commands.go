@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/database/migrations"
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/postgres"
+	"github.com/tmozzze/SQL_Converter/internal/service"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+// command - parsed command line of one command
+type command struct {
+	name string
+	file string
+
+	dsn     string
+	out     string
+	opts    models.UploadOptions
+	cfg     Config
+	json    bool
+	verbose bool
+
+	stdout io.Writer
+	stderr io.Writer
+	log    *slog.Logger
+}
+
+// parseCommand - parse flags and file argument of command
+func parseCommand(name string, args []string, stdout, stderr io.Writer) (*command, error) {
+	c := &command{name: name, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("sqlconv "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		configPath string
+		mode       string
+		dialect    string
+		onConflict string
+	)
+	fs.StringVar(&configPath, "config", "", "YAML config with analyzer and import sections (default built-in settings)")
+	fs.BoolVar(&c.json, "json", false, "print machine-readable JSON result to stdout")
+	fs.BoolVar(&c.verbose, "verbose", false, "print debug logs to stderr")
+
+	if name == "import" || name == "convert" {
+		fs.StringVar(&c.opts.Table, "table", "", "table name (default derived from filename), may contain date placeholders like sales_{yyyy_mm}")
+		fs.StringVar(&c.opts.Schema, "schema", "", "Postgres schema of table (default search_path)")
+	}
+
+	if name == "import" || name == "batch" {
+		fs.StringVar(&c.dsn, "dsn", "", "Postgres DSN, URL or key=value pairs (default $"+envDSN+")")
+	}
+
+	switch name {
+	case "import":
+		fs.StringVar(&mode, "mode", string(models.UploadModeReplace), "what to do with existing table: replace or append")
+		fs.BoolVar(&c.opts.Strict, "strict", false, "reject append if existing table schema differs from file")
+		fs.StringVar(&onConflict, "on-conflict", string(models.ConflictOverwrite), "what to do if table exists in replace mode: overwrite or suffix")
+		fs.BoolVar(&c.opts.Lineage, "lineage", false, "add lineage columns _import_id, _source_file, _source_sheet, _source_row_number, _loaded_at")
+		fs.StringVar(&c.opts.IdempotencyKey, "idempotency-key", "", "key of run, repeated run with the same key and file returns original result")
+		fs.BoolVar(&c.opts.Force, "force", false, "append file even if the same file is already appended to table")
+	case "convert":
+		fs.StringVar(&c.out, "out", "", "output .sql file, - for stdout (default <table>.sql)")
+		fs.StringVar(&dialect, "dialect", string(models.DialectPostgres), "SQL dialect of script: "+dialectNames())
+	}
+
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		if name == "batch" {
+			return nil, errors.New("exactly one manifest is required")
+		}
+		return nil, errors.New("exactly one file is required")
+	}
+	c.file = files[0]
+
+	switch models.UploadMode(mode) {
+	case "", models.UploadModeReplace, models.UploadModeAppend:
+		c.opts.Mode = models.UploadMode(mode)
+	default:
+		return nil, fmt.Errorf("invalid mode %q (use replace or append)", mode)
+	}
+
+	switch models.ConflictPolicy(onConflict) {
+	case "", models.ConflictOverwrite, models.ConflictSuffix:
+		c.opts.OnConflict = models.ConflictPolicy(onConflict)
+	default:
+		return nil, fmt.Errorf("invalid on-conflict %q (use overwrite or suffix)", onConflict)
+	}
+	if c.opts.Mode == models.UploadModeAppend && c.opts.OnConflict == models.ConflictSuffix {
+		return nil, errors.New("on-conflict suffix can't be used with append mode")
+	}
+
+	c.opts.Dialect = models.SQLDialect(dialect)
+	if dialect != "" && !slices.Contains(models.SQLDialects, c.opts.Dialect) {
+		return nil, fmt.Errorf("invalid dialect %q (use %s)", dialect, dialectNames())
+	}
+
+	// env is read after parsing, flag default would print secret DSN in usage
+	if c.dsn == "" {
+		c.dsn = os.Getenv(envDSN)
+	}
+	if (name == "import" || name == "batch") && c.dsn == "" {
+		return nil, fmt.Errorf("DSN is required (--dsn or $%s)", envDSN)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	c.cfg = cfg
+	c.log = setupLogger(stderr, c.verbose)
+
+	return c, nil
+}
+
+// parseFlags - parse flags before and after positional arguments (flag.FlagSet stops at the first one),
+// return positional arguments, everything after "--" is positional
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// runImport - analyze file, create table and load data to DB
+func runImport(ctx context.Context, c *command) (any, error) {
+	svc, closeDB, err := c.openService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	file, closeFile, err := c.openFile()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	started := time.Now()
+	result, err := svc.Processor().UploadFile(ctx, filepath.Base(c.file), file, c.extension(), c.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newImportOutput(result, time.Since(started)), nil
+}
+
+// runBatch - import files of YAML manifest (paths are relative to manifest)
+func runBatch(ctx context.Context, c *command) (any, error) {
+	f, err := os.Open(c.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// manifest is checked before connecting to DB
+	offline := service.NewService(postgres.NewRepository(nil, c.log), c.cfg.Analyzer, c.cfg.Import, c.log)
+	manifest, err := offline.Batches().ParseManifest(f)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, closeDB, err := c.openService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	dir := filepath.Dir(c.file)
+	open := func(name string) (io.ReadCloser, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		return os.Open(name)
+	}
+
+	report, err := svc.Batches().Run(ctx, manifest, open)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBatchOutput(report), nil
+}
+
+// runPreview - analyze file schema without DB
+func runPreview(ctx context.Context, c *command) (any, error) {
+	svc := service.NewService(postgres.NewRepository(nil, c.log), c.cfg.Analyzer, c.cfg.Import, c.log)
+
+	file, closeFile, err := c.openFile()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	table, err := svc.Processor().Preview(ctx, filepath.Base(c.file), file, c.extension())
+	if err != nil {
+		return nil, err
+	}
+
+	return newPreviewOutput(table), nil
+}
+
+// runConvert - write SQL script (CREATE TABLE and INSERTs) without DB
+func runConvert(ctx context.Context, c *command) (any, error) {
+	svc := service.NewService(postgres.NewRepository(nil, c.log), c.cfg.Analyzer, c.cfg.Import, c.log)
+
+	file, closeFile, err := c.openFile()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	// table name is known after analysis --> write to temp file, then move to <table>.sql
+	out := c.out
+	var w io.Writer = c.stdout
+	var tmp *os.File
+	if out != "-" {
+		dir := "."
+		if out != "" {
+			dir = filepath.Dir(out)
+		}
+		tmp, err = os.CreateTemp(dir, ".sqlconv-*.sql")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		w = tmp
+	}
+
+	result, err := svc.Processor().Convert(ctx, filepath.Base(c.file), file, c.extension(), c.opts, w)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmp != nil {
+		if out == "" {
+			out = result.Table.Name + ".sql"
+		}
+		if err := tmp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write output file: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), out); err != nil {
+			return nil, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+
+	output := newImportOutput(result, 0)
+	if tmp != nil {
+		output.Output = out
+	}
+	return output, nil
+}
+
+// openService - connect to DB, apply migrations of service-owned tables (imports catalog, profiles, ...)
+// and return service working with DB
+func (c *command) openService(ctx context.Context) (domain.Service, func(), error) {
+	db, err := database.OpenPostgres(c.dsn, config.PostgresCfg{MaxOpenConns: 8, MaxIdleConns: 8})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := database.NewMigrator(db, migrations.FS, c.log).Up(ctx); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	svc := service.NewService(postgres.NewRepository(db, c.log), c.cfg.Analyzer, c.cfg.Import, c.log)
+	return svc, func() { db.Close() }, nil
+}
+
+// openFile - open input file with progress on stderr
+func (c *command) openFile() (io.Reader, func(), error) {
+	f, err := os.Open(c.file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	// progress only for human on terminal
+	if c.json || !isTerminal(c.stderr) {
+		return f, func() { f.Close() }, nil
+	}
+
+	p := newProgressReader(f, size, filepath.Base(c.file), c.stderr)
+	return p, func() { p.done(); f.Close() }, nil
+}
+
+// extension - return lower-case extension of input file
+func (c *command) extension() string {
+	return strings.ToLower(filepath.Ext(c.file))
+}
+
+// dialectNames - return comma separated names of supported SQL dialects
+func dialectNames() string {
+	names := make([]string, len(models.SQLDialects))
+	for i, d := range models.SQLDialects {
+		names[i] = string(d)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+)
+
+// Exit codes of sqlconv, stable for scripts
+const (
+	exitOK                   = 0
+	exitInternal             = 1
+	exitUsage                = 2
+	exitUnsupportedExtension = 3
+	exitEmptyData            = 4
+	exitTooManyErrors        = 5
+	exitSchemaDrift          = 6
+	exitInvalidName          = 7
+	exitSchemaNotAllowed     = 8
+	exitTableExists          = 9
+	exitIdempotencyKeyReused = 10
+	exitTableNotFound        = 11
+	exitInvalidManifest      = 12
+	exitBatchFailed          = 13
+	exitCanceled             = 130
+)
+
+// exitCode - return exit code of domain error
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedExtension):
+		return exitUnsupportedExtension
+	case errors.Is(err, domain.ErrEmptyData), errors.Is(err, domain.ErrNoColumns):
+		return exitEmptyData
+	case errors.Is(err, domain.ErrTooManyErrors):
+		return exitTooManyErrors
+	case errors.Is(err, domain.ErrSchemaDrift):
+		return exitSchemaDrift
+	case errors.Is(err, domain.ErrInvalidTableName), errors.Is(err, domain.ErrInvalidSchema):
+		return exitInvalidName
+	case errors.Is(err, domain.ErrSchemaNotAllowed):
+		return exitSchemaNotAllowed
+	case errors.Is(err, domain.ErrTableExists):
+		return exitTableExists
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return exitIdempotencyKeyReused
+	case errors.Is(err, domain.ErrTableNotFound):
+		return exitTableNotFound
+	case errors.Is(err, domain.ErrInvalidManifest):
+		return exitInvalidManifest
+	case errors.Is(err, domain.ErrUnsupportedDialect):
+		return exitUsage
+	case errors.Is(err, context.Canceled):
+		return exitCanceled
+	default:
+		return exitInternal
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// envDSN - environment variable with DSN used when --dsn flag is not set
+const envDSN = "SQLCONV_DSN"
+
+const usage = `sqlconv - convert CSV/XLSX files to PostgreSQL tables
+
+Usage:
+  sqlconv import  [flags] <file>       analyze file, create table and load data to DB
+  sqlconv preview [flags] <file>       analyze file schema without DB
+  sqlconv convert [flags] <file>       write SQL script (CREATE TABLE and INSERTs) without DB,
+                                       for postgres, mysql, sqlite, clickhouse or mssql (--dialect)
+  sqlconv batch   [flags] <manifest>   import files listed in YAML manifest
+
+Flags may be given before or after file. Run "sqlconv <command> -h" for flags of command.
+`
+
+// Config - settings of analyzer and import (same sections as config of API)
+type Config struct {
+	Analyzer config.AnalyzerCfg `yaml:"analyzer"`
+	Import   config.ImportCfg   `yaml:"import"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run - run command with args, return exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var cmd func(ctx context.Context, c *command) (any, error)
+	switch args[0] {
+	case "import":
+		cmd = runImport
+	case "preview":
+		cmd = runPreview
+	case "convert":
+		cmd = runConvert
+	case "batch":
+		cmd = runBatch
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	c, err := parseCommand(args[0], args[1:], stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", args[0], err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := cmd(ctx, c)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if err := c.print(result); err != nil {
+		fmt.Fprintf(stderr, "failed to write result: %s\n", err)
+		return exitInternal
+	}
+
+	// report is printed, but some files of batch failed
+	if report, ok := result.(batchOutput); ok && report.Status != string(models.BatchStatusSuccess) {
+		return exitBatchFailed
+	}
+	return exitOK
+}
+
+// loadConfig - read config file (path "" - defaults)
+func loadConfig(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return Config{}, fmt.Errorf("cannot read default config: %w", err)
+		}
+		return cfg, nil
+	}
+
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		return Config{}, fmt.Errorf("cannot read config: %w", err)
+	}
+	return cfg, nil
+}
+
+// setupLogger - text logger to stderr, only warnings unless verbose (stdout is kept for result)
+func setupLogger(w io.Writer, verbose bool) *slog.Logger {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// columnOutput - column of analyzed table
+type columnOutput struct {
+	Name       string   `json:"name"`
+	Source     string   `json:"source,omitempty"`
+	Type       string   `json:"type"`
+	EnumValues []string `json:"enum_values,omitempty"`
+	Confidence float64  `json:"confidence"`
+}
+
+// rejectOutput - row which was not loaded
+type rejectOutput struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// changeOutput - change of existing table
+type changeOutput struct {
+	Kind   string `json:"kind"`
+	Column string `json:"column"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+}
+
+// previewOutput - result of preview
+type previewOutput struct {
+	Status  string         `json:"status"`
+	Table   string         `json:"table"`
+	Columns []columnOutput `json:"columns"`
+}
+
+// importOutput - result of import and convert
+type importOutput struct {
+	previewOutput
+	ImportID int64          `json:"import_id,omitempty"`
+	Rows     int            `json:"rows"`
+	Rejected int            `json:"rejected"`
+	Rejects  []rejectOutput `json:"rejects,omitempty"`
+	Changes  []changeOutput `json:"changes,omitempty"`
+	Replayed bool           `json:"replayed,omitempty"`
+	// Output - written .sql file of convert ("" - stdout)
+	Output   string  `json:"output,omitempty"`
+	Duration float64 `json:"duration_seconds,omitempty"`
+}
+
+// batchOutput - consolidated report of batch
+type batchOutput struct {
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Files    []batchFileOutput `json:"files"`
+	Duration float64           `json:"duration_seconds"`
+}
+
+// batchFileOutput - result of file of batch
+type batchFileOutput struct {
+	Name     string        `json:"name"`
+	File     string        `json:"file"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration float64       `json:"duration_seconds"`
+	Result   *importOutput `json:"result,omitempty"`
+}
+
+// errorOutput - result of failed command
+type errorOutput struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Code   int    `json:"code"`
+	// Changes - changes of existing table which strict append would require
+	Changes []changeOutput `json:"changes,omitempty"`
+}
+
+func newPreviewOutput(table models.Table) previewOutput {
+	columns := make([]columnOutput, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = columnOutput{
+			Name:       col.Name,
+			Source:     col.Source,
+			Type:       col.Type.String(),
+			EnumValues: col.EnumValues,
+			Confidence: col.Confidence,
+		}
+	}
+	return previewOutput{Status: "OK", Table: table.QualifiedName(), Columns: columns}
+}
+
+func newImportOutput(result models.ImportResult, duration time.Duration) importOutput {
+	rejects := make([]rejectOutput, len(result.Rejects))
+	for i, r := range result.Rejects {
+		rejects[i] = rejectOutput{Row: r.Row, Column: r.Column, Value: r.Value, Reason: r.Reason}
+	}
+
+	return importOutput{
+		previewOutput: newPreviewOutput(result.Table),
+		ImportID:      result.ImportID,
+		Rows:          result.Rows,
+		Rejected:      result.Rejected,
+		Rejects:       rejects,
+		Changes:       newChangesOutput(result.Changes),
+		Replayed:      result.Replayed,
+		Duration:      duration.Seconds(),
+	}
+}
+
+func newBatchOutput(report models.BatchReport) batchOutput {
+	out := batchOutput{
+		Status:   string(report.Status),
+		Error:    report.Error,
+		Files:    make([]batchFileOutput, len(report.Steps)),
+		Duration: report.Duration.Seconds(),
+	}
+	for i, step := range report.Steps {
+		out.Files[i] = batchFileOutput{
+			Name:     step.Name,
+			File:     step.File,
+			Status:   string(step.Status),
+			Error:    step.Error,
+			Duration: step.Duration.Seconds(),
+		}
+		if step.Status == models.BatchStatusSuccess || step.Status == models.BatchStatusRolledBack {
+			result := newImportOutput(step.Result, step.Duration)
+			out.Files[i].Result = &result
+		}
+	}
+	return out
+}
+
+func newChangesOutput(changes []models.SchemaChange) []changeOutput {
+	out := make([]changeOutput, len(changes))
+	for i, c := range changes {
+		out[i] = changeOutput{Kind: string(c.Kind), Column: c.Column, From: c.From, To: c.To}
+	}
+	return out
+}
+
+// print - write result to stdout as JSON or text
+func (c *command) print(result any) error {
+	if c.json {
+		enc := json.NewEncoder(c.resultWriter())
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	tw := tabwriter.NewWriter(c.resultWriter(), 0, 0, 2, ' ', 0)
+
+	var out importOutput
+	switch r := result.(type) {
+	case previewOutput:
+		out.previewOutput = r
+	case importOutput:
+		out = r
+	case batchOutput:
+		printBatch(tw, r)
+		return tw.Flush()
+	}
+
+	fmt.Fprintf(tw, "table %s\n\n", out.Table)
+	fmt.Fprintln(tw, "COLUMN\tTYPE\tSOURCE\tCONFIDENCE")
+	for _, col := range out.Columns {
+		typ := col.Type
+		if len(col.EnumValues) > 0 {
+			typ += " (enum: " + strings.Join(col.EnumValues, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0f%%\n", col.Name, typ, col.Source, col.Confidence*100)
+	}
+
+	if c.name != "preview" {
+		fmt.Fprintln(tw)
+		for _, ch := range out.Changes {
+			if ch.From == "" {
+				fmt.Fprintf(tw, "added column %s %s\n", ch.Column, ch.To)
+			} else {
+				fmt.Fprintf(tw, "widened column %s %s -> %s\n", ch.Column, ch.From, ch.To)
+			}
+		}
+		for _, r := range out.Rejects {
+			fmt.Fprintf(tw, "rejected row %d\t%s\t%s\n", r.Row, r.Column, r.Reason)
+		}
+
+		switch {
+		case out.Replayed:
+			fmt.Fprintf(tw, "file is already loaded by import #%d: %d rows, %d rejected\n", out.ImportID, out.Rows, out.Rejected)
+		case c.name == "convert" && out.Output == "":
+			fmt.Fprintf(tw, "%d rows written to stdout, %d rejected\n", out.Rows, out.Rejected)
+		case c.name == "convert":
+			fmt.Fprintf(tw, "%d rows written to %s, %d rejected\n", out.Rows, out.Output, out.Rejected)
+		default:
+			fmt.Fprintf(tw, "%d rows loaded, %d rejected in %.1fs (import #%d)\n", out.Rows, out.Rejected, out.Duration, out.ImportID)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// printBatch - write report of batch as table
+func printBatch(w io.Writer, report batchOutput) {
+	fmt.Fprintln(w, "NAME\tTABLE\tSTATUS\tROWS\tREJECTED\tERROR")
+	for _, f := range report.Files {
+		var table string
+		var rows, rejected int
+		if f.Result != nil {
+			table, rows, rejected = f.Result.Table, f.Result.Rows, f.Result.Rejected
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", f.Name, table, f.Status, rows, rejected, f.Error)
+	}
+
+	fmt.Fprintf(w, "\nbatch %s in %.1fs\n", report.Status, report.Duration)
+	if report.Error != "" {
+		fmt.Fprintln(w, report.Error)
+	}
+}
+
+// fail - report error to result writer (JSON) or stderr (text), return exit code
+func (c *command) fail(err error) int {
+	code := exitCode(err)
+
+	if c.json {
+		out := errorOutput{Status: "error", Error: err.Error(), Code: code}
+		var drift *domain.SchemaDriftError
+		if errors.As(err, &drift) {
+			out.Changes = newChangesOutput(drift.Changes)
+		}
+		enc := json.NewEncoder(c.resultWriter())
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(out); encErr != nil {
+			fmt.Fprintf(c.stderr, "%s: %s\n", c.name, err)
+		}
+		return code
+	}
+
+	fmt.Fprintf(c.stderr, "%s: %s\n", c.name, err)
+	return code
+}
+
+// resultWriter - stdout, or stderr if stdout is taken by script (convert --out -)
+func (c *command) resultWriter() io.Writer {
+	if c.out == "-" {
+		return c.stderr
+	}
+	return c.stdout
+}
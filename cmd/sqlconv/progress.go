@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// progressReader - reader which prints share of read bytes to w
+type progressReader struct {
+	r       io.Reader
+	w       io.Writer
+	name    string
+	size    int64
+	read    int64
+	percent int64
+	eof     bool
+}
+
+func newProgressReader(r io.Reader, size int64, name string, w io.Writer) *progressReader {
+	return &progressReader{r: r, w: w, name: name, size: size, percent: -1}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+
+	if p.size > 0 {
+		if percent := min(p.read*100/p.size, 100); percent != p.percent {
+			p.percent = percent
+			fmt.Fprintf(p.w, "\rreading %s: %3d%% (%s / %s)", p.name, percent, formatBytes(p.read), formatBytes(p.size))
+		}
+	}
+
+	// file is read --> analysis and loading start
+	if err == io.EOF && !p.eof {
+		p.eof = true
+		fmt.Fprintf(p.w, "\rreading %s: done (%s)          \nanalyzing and loading...\n", p.name, formatBytes(p.read))
+	}
+
+	return n, err
+}
+
+// done - finish progress line if file was not read to the end (error)
+func (p *progressReader) done() {
+	if !p.eof && p.percent >= 0 {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// formatBytes - return human-readable size
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal - report whether w is a terminal (progress is noise in pipes and logs)
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
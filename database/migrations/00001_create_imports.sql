@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS imports (
+	id BIGSERIAL PRIMARY KEY,
+	filename TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	size_bytes BIGINT NOT NULL,
+	rows_count BIGINT NOT NULL,
+	columns_count INT NOT NULL,
+	rejected_count BIGINT NOT NULL,
+	schema JSONB,
+	duration_ms BIGINT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS imports_table_name_idx ON imports (table_name);
+
+-- +goose Down
+DROP TABLE IF EXISTS imports;
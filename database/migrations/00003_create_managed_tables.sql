@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS managed_tables (
+	name TEXT PRIMARY KEY,
+	last_import_id BIGINT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- tables imported before catalog existed
+INSERT INTO managed_tables (name, last_import_id, created_at, updated_at)
+SELECT table_name, max(id), min(created_at), max(created_at)
+FROM imports
+WHERE status = 'success' AND to_regclass(quote_ident(table_name)) IS NOT NULL
+GROUP BY table_name
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS managed_tables;
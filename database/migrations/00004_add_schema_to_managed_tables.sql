@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE managed_tables ADD COLUMN IF NOT EXISTS schema_name TEXT NOT NULL DEFAULT current_schema();
+
+ALTER TABLE managed_tables DROP CONSTRAINT managed_tables_pkey;
+ALTER TABLE managed_tables ADD PRIMARY KEY (schema_name, name);
+
+-- +goose Down
+DELETE FROM managed_tables WHERE schema_name <> current_schema();
+
+ALTER TABLE managed_tables DROP CONSTRAINT managed_tables_pkey;
+ALTER TABLE managed_tables ADD PRIMARY KEY (name);
+
+ALTER TABLE managed_tables DROP COLUMN IF EXISTS schema_name;
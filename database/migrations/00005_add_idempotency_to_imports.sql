@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE imports ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE INDEX IF NOT EXISTS imports_idempotency_key_idx ON imports (idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS imports_table_name_checksum_idx ON imports (table_name, checksum);
+
+-- +goose Down
+DROP INDEX IF EXISTS imports_table_name_checksum_idx;
+DROP INDEX IF EXISTS imports_idempotency_key_idx;
+
+ALTER TABLE imports DROP COLUMN IF EXISTS idempotency_key;
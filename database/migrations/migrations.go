@@ -0,0 +1,25 @@
+// Package migrations - SQL migrations of service-owned tables (goose format)
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS - migrations embedded in binary (PostgreSQL)
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite - return embedded migrations of SQLite backend
+func SQLite() fs.FS {
+	sub, err := fs.Sub(sqliteFS, "sqlite")
+	if err != nil {
+		// directory is embedded, can't be missing
+		panic(err)
+	}
+	return sub
+}
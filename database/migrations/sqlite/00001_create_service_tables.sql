@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS imports (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	filename TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	size_bytes INTEGER NOT NULL,
+	rows_count INTEGER NOT NULL,
+	columns_count INTEGER NOT NULL,
+	rejected_count INTEGER NOT NULL,
+	schema TEXT,
+	duration_ms INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	idempotency_key TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS imports_table_name_checksum_idx ON imports (table_name, checksum);
+CREATE INDEX IF NOT EXISTS imports_idempotency_key_idx ON imports (idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS table_profiles (
+	table_name TEXT PRIMARY KEY,
+	profile TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS managed_tables (
+	schema_name TEXT NOT NULL DEFAULT 'main',
+	name TEXT NOT NULL,
+	last_import_id INTEGER,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (schema_name, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS managed_tables;
+DROP TABLE IF EXISTS table_profiles;
+DROP TABLE IF EXISTS imports;
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
 	"time"
@@ -13,22 +14,29 @@ type Config struct {
 	Env           string      `yaml:"env" env-default:"local"`
 	HTTPServer    HTTPServer  `yaml:"http_server"`
 	Postgres      PostgresCfg `yaml:"postgres"`
+	SQLite        SQLiteCfg   `yaml:"sqlite"`
 	MigrationsDir string      `yaml:"migrations_dir" env-default:"./database/migrations"`
-	DBDialect     string      `yaml:"db_dialect" env-default:"postgres"`
+	DBDialect     string      `yaml:"db_dialect" env-default:"postgres"` // postgres or sqlite (embedded DB in one file)
+	Analyzer      AnalyzerCfg `yaml:"analyzer"`
+	Import        ImportCfg   `yaml:"import"`
+	Watcher       WatcherCfg  `yaml:"watcher"`
 }
 
 type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	// MaxUploadSize - max size of upload request body in bytes (all files of form), above it 413 is returned
+	MaxUploadSize int64 `yaml:"max_upload_size" env-default:"104857600"`
 }
 
 type PostgresCfg struct {
-	Host     string `yaml:"host" env:"POSTGRES_HOST" env-required:"true"`
+	// Host, User, Password, DBName - required for postgres dialect (checked by Validate)
+	Host     string `yaml:"host" env:"POSTGRES_HOST"`
 	Port     string `yaml:"port" env:"POSTGRES_INTERNAL_PORT" env-default:"5432"`
-	User     string `yaml:"user" env:"POSTGRES_USER" env-required:"true"`
-	Password string `yaml:"password" env:"POSTGRES_PASSWORD" env-required:"true"`
-	DBName   string `yaml:"dbname" env:"POSTGRES_DB" env-required:"true"`
+	User     string `yaml:"user" env:"POSTGRES_USER"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD"`
+	DBName   string `yaml:"dbname" env:"POSTGRES_DB"`
 	SSLMode  string `yaml:"sslmode" env:"POSTGRES_SSLMODE" env-default:"disable"`
 	TZ       string `yaml:"tz" env:"TZ" env-default:"Europe/Moscow"`
 
@@ -37,6 +45,91 @@ type PostgresCfg struct {
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
 }
 
+// SQLiteCfg - settings of embedded SQLite DB
+type SQLiteCfg struct {
+	// Path - DB file, created with its directory if missing
+	Path string `yaml:"path" env:"SQLITE_PATH" env-default:"./data/sql_converter.db"`
+}
+
+// AnalyzerCfg - settings for schema analyzer and value converter
+type AnalyzerCfg struct {
+	BoolTrue    []string `yaml:"bool_true" env-default:"true,yes,y,on,да,д,✓,✔"`
+	BoolFalse   []string `yaml:"bool_false" env-default:"false,no,n,off,нет,н,✗,✘"`
+	NumericBool bool     `yaml:"numeric_bool" env-default:"false"`
+
+	// EnumThreshold - max distinct values of enum column (0 - disabled)
+	EnumThreshold int `yaml:"enum_threshold" env-default:"0"`
+	// EnumMode - "check" (CHECK constraint) or "type" (CREATE TYPE ... AS ENUM)
+	EnumMode string `yaml:"enum_mode" env-default:"check"`
+
+	// ProfileTopK - number of most frequent values in column profile
+	ProfileTopK int `yaml:"profile_top_k" env-default:"5"`
+	// ProfileSamples - number of sample values in column profile
+	ProfileSamples int `yaml:"profile_samples" env-default:"5"`
+
+	// SampleHead - number of first rows always analyzed (0 - analyze all rows)
+	SampleHead int `yaml:"sample_head" env-default:"0"`
+	// SampleSize - number of rows randomly sampled from the rest (reservoir sampling)
+	SampleSize int `yaml:"sample_size" env-default:"10000"`
+	// TolerancePercent - column keeps numeric type when less than X% of values are invalid
+	TolerancePercent float64 `yaml:"tolerance_percent" env-default:"0"`
+
+	// NamingPolicy - column names: "keep" (as in header), "snake_case" or "translit" (snake_case in Latin)
+	NamingPolicy string `yaml:"naming_policy" env-default:"keep"`
+}
+
+// ImportCfg - settings for loading data
+type ImportCfg struct {
+	// MaxErrors - max rejected rows, import is aborted above it (0 - no limit by count)
+	MaxErrors int `yaml:"max_errors" env-default:"0"`
+	// MaxErrorPercent - max share of rejected rows in percent (0 - no limit by percent).
+	// With both limits 0 a failed insert aborts the whole import.
+	MaxErrorPercent float64 `yaml:"max_error_percent" env-default:"0"`
+
+	// AllowedSchemas - schemas which can be chosen for upload (without choice - default search_path)
+	AllowedSchemas []string `yaml:"allowed_schemas" env-default:"public"`
+
+	// IdempotencyWindow - how long repeated upload (same Idempotency-Key, or same file appended
+	// to the same table) returns original result instead of loading again (0 - disabled)
+	IdempotencyWindow time.Duration `yaml:"idempotency_window" env-default:"24h"`
+
+	// BatchParallelism - max number of files of batch imported at the same time (default of manifest)
+	BatchParallelism int `yaml:"batch_parallelism" env-default:"4"`
+
+	// MaxUnzippedSize - max total uncompressed size in bytes of CSV and XLSX entries of .zip archive (0 - no limit)
+	MaxUnzippedSize int64 `yaml:"max_unzipped_size" env-default:"1073741824"`
+}
+
+// WatcherCfg - settings for hot-folder ingestion (watch subcommand)
+type WatcherCfg struct {
+	// PollInterval - how often directories are scanned for new files
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	// SettleTime - file is imported only after it was not modified for this time and its size
+	// did not change between two scans (partially written files are skipped)
+	SettleTime time.Duration `yaml:"settle_time" env-default:"10s"`
+	Dirs       []WatchDirCfg `yaml:"dirs"`
+}
+
+// WatchDirCfg - watched directory with upload settings of its files
+type WatchDirCfg struct {
+	Path string `yaml:"path"`
+	// Table - table name, may contain date placeholders ("" - derived from filename)
+	Table      string `yaml:"table"`
+	Mode       string `yaml:"mode"`
+	Schema     string `yaml:"schema"`
+	OnConflict string `yaml:"on_conflict"`
+	Strict     bool   `yaml:"strict"`
+	Lineage    bool   `yaml:"lineage"`
+}
+
+// Validate - check that connection settings are set
+func (p PostgresCfg) Validate() error {
+	if p.Host == "" || p.User == "" || p.Password == "" || p.DBName == "" {
+		return errors.New("POSTGRES_HOST, POSTGRES_USER, POSTGRES_PASSWORD and POSTGRES_DB are required")
+	}
+	return nil
+}
+
 func (p PostgresCfg) DSN() string {
 	return "host=" + p.Host +
 		" user=" + p.User +
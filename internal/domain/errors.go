@@ -1,9 +1,56 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
 
 var (
 	ErrUnsupportedExtension = errors.New("unsupported extension")
 	ErrEmptyData            = errors.New("file is empty or has no data rows")
 	ErrNoColumns            = errors.New("no columns")
+	ErrTableNotFound        = errors.New("table not found")
+	ErrTooManyErrors        = errors.New("too many rejected rows")
+	ErrImportNotFound       = errors.New("import not found")
+	ErrTableExists          = errors.New("table already exists")
+	ErrInvalidTableName     = errors.New("invalid table name")
+	ErrColumnNotFound       = errors.New("column not found")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrSchemaDrift          = errors.New("existing table schema differs from file")
+	ErrInvalidSchema        = errors.New("invalid schema name")
+	ErrSchemaNotAllowed     = errors.New("schema is not allowed")
+	ErrIdempotencyKeyReused = errors.New("idempotency key is already used for another file")
+	ErrInvalidManifest      = errors.New("invalid batch manifest")
+	ErrUnsupportedDialect   = errors.New("unsupported SQL dialect")
+	ErrInvalidColumnName    = errors.New("invalid column name")
+	ErrFileTooLarge         = errors.New("file is too large")
+	ErrRowRejected          = errors.New("row is rejected by database")
 )
+
+// SchemaDriftError - error of strict append, contains changes which would be required
+type SchemaDriftError struct {
+	Changes []models.SchemaChange
+}
+
+func (e *SchemaDriftError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		switch c.Kind {
+		case models.SchemaChangeAddColumn:
+			changes[i] = fmt.Sprintf("new column %s %s", c.Column, c.To)
+		case models.SchemaChangeDropCheck:
+			changes[i] = fmt.Sprintf("new values of enum column %s", c.Column)
+		default:
+			changes[i] = fmt.Sprintf("column %s %s -> %s", c.Column, c.From, c.To)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrSchemaDrift, strings.Join(changes, ", "))
+}
+
+// Is - match ErrSchemaDrift
+func (e *SchemaDriftError) Is(target error) bool {
+	return target == ErrSchemaDrift
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// BatchManifest - represent a batch of files imported together
+type BatchManifest struct {
+	// Parallelism - max number of files imported at the same time
+	Parallelism int
+	// AllOrNothing - import files one by one in one transaction, any failure rolls back the whole batch
+	AllOrNothing bool
+	Steps        []BatchStep
+}
+
+// BatchStep - represent a file of batch with its upload settings
+type BatchStep struct {
+	// Name - id of step for DependsOn (default file)
+	Name string
+	// File - path of file (relative to manifest) or name of uploaded file
+	File    string
+	Options UploadOptions
+	// DependsOn - steps which must succeed before this one starts
+	DependsOn []string
+}
+
+// BatchStatus - represent a result of batch or its step
+type BatchStatus string
+
+const (
+	BatchStatusSuccess BatchStatus = "success"
+	BatchStatusFailed  BatchStatus = "failed"
+	// BatchStatusPartial - some steps failed or were skipped (batch without all-or-nothing)
+	BatchStatusPartial BatchStatus = "partial"
+	// BatchStatusSkipped - step was not run: dependency failed or batch was stopped
+	BatchStatusSkipped BatchStatus = "skipped"
+	// BatchStatusRolledBack - step succeeded, but all-or-nothing batch failed and was rolled back
+	BatchStatusRolledBack BatchStatus = "rolled_back"
+)
+
+// BatchReport - represent a consolidated result of batch
+type BatchReport struct {
+	Status BatchStatus
+	// Error - reason of failed all-or-nothing batch which is not a step failure (e.g. commit failed)
+	Error    string
+	Steps    []BatchStepResult
+	Duration time.Duration
+}
+
+// BatchStepResult - represent a result of batch step
+type BatchStepResult struct {
+	Name     string
+	File     string
+	Status   BatchStatus
+	Result   ImportResult
+	Error    string
+	Duration time.Duration
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// TableInfo - represent a table created by service
+type TableInfo struct {
+	Schema    string
+	Name      string
+	Columns   []ColumnInfo
+	Rows      int64
+	SizeBytes int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ColumnInfo - represent a column of existing table (type as in DB)
+type ColumnInfo struct {
+	Name string
+	Type string
+}
+
+// TableFilter - represent a filter of tables list
+type TableFilter struct {
+	// Schema - exact schema of tables ("" - all schemas)
+	Schema string
+	// Name - substring of table name (case-insensitive)
+	Name   string
+	Limit  int
+	Offset int
+}
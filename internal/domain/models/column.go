@@ -4,4 +4,23 @@ package models
 type Column struct {
 	Type DataType
 	Name string
+	// Source - original header of column in file
+	Source string
+	// EnumValues - allowed values of low-cardinality column (nil - not enum)
+	EnumValues []string
+	// Profile - statistics of column values
+	Profile ColumnProfile
+	// Confidence - share of analyzed values confirming the type (0..1)
+	Confidence float64
+	// Downgrades - values which forced type changes while analyzing
+	Downgrades []TypeChange
+}
+
+// TypeChange - represent a column type change forced by a value
+type TypeChange struct {
+	From  DataType
+	To    DataType
+	Value string
+	// Row - data row number (1-based, without header)
+	Row int
 }
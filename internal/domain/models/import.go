@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ImportStatus - represent a status of file import
+type ImportStatus string
+
+const (
+	ImportStatusSuccess ImportStatus = "success"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+// Import - represent a record of file import in catalog
+type Import struct {
+	ID        int64
+	Filename  string
+	TableName string
+	// Checksum - SHA-256 of file content (hex)
+	Checksum string
+	Size     int64
+	Rows     int
+	Columns  int
+	Rejected int
+	Schema   Table
+	Duration time.Duration
+	Status   ImportStatus
+	Error    string
+	// IdempotencyKey - key of upload request ("" - not given)
+	IdempotencyKey string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Lineage - represent origin of imported rows, written to lineage columns of table
+type Lineage struct {
+	ImportID    int64
+	SourceFile  string
+	SourceSheet string
+	LoadedAt    time.Time
+	// RowNumbers - data row numbers (1-based) in source file of saved rows (nil - position in saved data)
+	RowNumbers []int
+}
+
+// RowNumber - return number of source row for i-th saved row (0-based)
+func (l *Lineage) RowNumber(i int) int {
+	if i < len(l.RowNumbers) {
+		return l.RowNumbers[i]
+	}
+	return i + 1
+}
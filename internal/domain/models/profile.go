@@ -0,0 +1,21 @@
+package models
+
+// ValueCount - represent a value with number of occurrences
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// ColumnProfile - represent statistics of column values
+type ColumnProfile struct {
+	NullCount     int
+	DistinctCount int
+	// Min, Max - numeric for numbers, lexicographic otherwise (empty - no values)
+	Min string
+	Max string
+	// Mean - only for numeric columns (nil - not numeric or no values)
+	Mean      *float64
+	MaxLength int
+	TopValues []ValueCount
+	Samples   []string
+}
@@ -0,0 +1,32 @@
+package models
+
+// ImportResult - represent a result of file import
+type ImportResult struct {
+	// ImportID - id of record in imports catalog (0 - not recorded)
+	ImportID int64
+	Table    Table
+	Rows     int
+	Rejected int
+	Rejects  []RejectedRow
+	// Changes - changes of existing table made in append mode
+	Changes []SchemaChange
+	// Replayed - upload is a repeat of import ImportID, nothing was loaded
+	Replayed bool
+}
+
+// RejectedRow - represent a row which was not loaded to table
+type RejectedRow struct {
+	// Row - data row number (1-based, without header)
+	Row    int
+	Column string
+	Value  string
+	Reason string
+	Values []string
+}
+
+// TableData - represent analyzed table with its converted rows and rows failed conversion
+type TableData struct {
+	Table   Table
+	Rows    [][]string
+	Rejects []RejectedRow
+}
@@ -0,0 +1,28 @@
+package models
+
+// RowsQuery - represent a query of table rows
+type RowsQuery struct {
+	// Columns - selected columns (empty - all columns)
+	Columns []string
+	// Filters - equality filters (column = value, compared as text)
+	Filters []Filter
+	Limit   int
+	Offset  int
+	// After - keyset cursor from RowsPage.NextCursor (used instead of Offset)
+	After string
+}
+
+// Filter - represent an equality filter
+type Filter struct {
+	Column string
+	Value  string
+}
+
+// RowsPage - represent a page of table rows
+type RowsPage struct {
+	Columns []ColumnInfo
+	// Rows - values in order of Columns (nil, int64, float64, bool, string, time.Time)
+	Rows [][]any
+	// NextCursor - cursor of next page ("" - last page)
+	NextCursor string
+}
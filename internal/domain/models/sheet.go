@@ -0,0 +1,7 @@
+package models
+
+// Sheet - represent parsed rows of file (CSV file is one sheet without name)
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
@@ -1,7 +1,40 @@
 package models
 
+import "strings"
+
+// EnumMode - represent a way to restrict enum columns
+type EnumMode string
+
+const (
+	EnumModeNone  EnumMode = ""
+	EnumModeCheck EnumMode = "check"
+	EnumModeType  EnumMode = "type"
+)
+
 // Table - represent a table
 type Table struct {
-	Name    string
-	Columns []Column
+	// Schema - Postgres schema (namespace) of table ("" - default search_path)
+	Schema   string
+	Name     string
+	Columns  []Column
+	EnumMode EnumMode
+	// Lineage - origin of rows for lineage columns (nil - table has no lineage columns)
+	Lineage *Lineage
+}
+
+// QualifiedName - return "schema.name" ("name" for default schema)
+func (t Table) QualifiedName() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// SplitQualifiedName - split "schema.name" to schema and name ("" schema for "name")
+func SplitQualifiedName(qualified string) (string, string) {
+	schema, name, ok := strings.Cut(qualified, ".")
+	if !ok {
+		return "", qualified
+	}
+	return schema, name
 }
@@ -9,6 +9,11 @@ const (
 	DataTypeFloat
 	DataTypeBoolean
 	DataTypeString
+	DataTypeUUID
+	DataTypeJSON
+	DataTypeInet
+	DataTypeCIDR
+	DataTypeInterval
 )
 
 // String - return a DataType string
@@ -22,8 +27,28 @@ func (d DataType) String() string {
 		return "Boolean"
 	case DataTypeString:
 		return "String"
+	case DataTypeUUID:
+		return "UUID"
+	case DataTypeJSON:
+		return "JSON"
+	case DataTypeInet:
+		return "Inet"
+	case DataTypeCIDR:
+		return "CIDR"
+	case DataTypeInterval:
+		return "Interval"
 	default:
 		return "Unknown"
 	}
 
 }
+
+// ParseDataType - return a DataType by its string
+func ParseDataType(s string) DataType {
+	for t := DataTypeInteger; t <= DataTypeInterval; t++ {
+		if t.String() == s {
+			return t
+		}
+	}
+	return DataTypeUnknown
+}
@@ -0,0 +1,18 @@
+package models
+
+// UnionResult - represent a result of loading several files into one table
+type UnionResult struct {
+	// ImportResult - result of the whole table, rejected rows are numbered through all files in upload order
+	ImportResult
+	Files []UnionFile
+}
+
+// UnionFile - represent a file loaded into union table
+type UnionFile struct {
+	// Name - uploaded file name (entry path for file of zip archive)
+	Name     string
+	Rows     int
+	Rejected int
+	// Missing - columns of table absent in file (filled with NULL)
+	Missing []string
+}
@@ -0,0 +1,78 @@
+package models
+
+// UploadMode - represent what to do with existing table on upload
+type UploadMode string
+
+const (
+	// UploadModeReplace - drop existing table and create it again (default)
+	UploadModeReplace UploadMode = "replace"
+	// UploadModeAppend - add rows to existing table, evolving its schema
+	UploadModeAppend UploadMode = "append"
+)
+
+// ConflictPolicy - represent what to do if target table already exists (replace mode)
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite - replace existing table (default)
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSuffix - create new table with first free suffix _v2, _v3, ...
+	ConflictSuffix ConflictPolicy = "suffix"
+)
+
+// SQLDialect - represent target database of SQL script
+type SQLDialect string
+
+const (
+	DialectPostgres   SQLDialect = "postgres"
+	DialectMySQL      SQLDialect = "mysql"
+	DialectSQLite     SQLDialect = "sqlite"
+	DialectClickHouse SQLDialect = "clickhouse"
+	DialectMSSQL      SQLDialect = "mssql"
+)
+
+// SQLDialects - supported dialects of SQL script
+var SQLDialects = []SQLDialect{DialectPostgres, DialectMySQL, DialectSQLite, DialectClickHouse, DialectMSSQL}
+
+// UploadOptions - represent options of file upload
+type UploadOptions struct {
+	Mode UploadMode
+	// Table - explicit table name, may contain date placeholders like {yyyy_mm} ("" - derived from filename)
+	Table string
+	// OnConflict - what to do if table already exists
+	OnConflict ConflictPolicy
+	// Strict - reject append if existing table schema differs from file
+	Strict bool
+	// Schema - Postgres schema of table ("" - default search_path)
+	Schema string
+	// Lineage - add lineage columns (_import_id, _source_file, ...) to every imported row
+	Lineage bool
+	// IdempotencyKey - key of upload request, repeated request returns original result
+	IdempotencyKey string
+	// Force - append file even if the same file is already appended to table
+	Force bool
+	// Dialect - target database of offline SQL script ("" - postgres)
+	Dialect SQLDialect
+	// SourceColumn - column with name of source file of every row for union of files ("" - no column)
+	SourceColumn string
+}
+
+// SchemaChangeKind - represent a kind of change of existing table
+type SchemaChangeKind string
+
+const (
+	SchemaChangeAddColumn SchemaChangeKind = "add_column"
+	SchemaChangeWidenType SchemaChangeKind = "widen_type"
+	// SchemaChangeDropCheck - drop CHECK restriction of enum column which does not allow new values
+	SchemaChangeDropCheck SchemaChangeKind = "drop_check"
+)
+
+// SchemaChange - represent a change of existing table required to append file
+type SchemaChange struct {
+	Kind   SchemaChangeKind
+	Column string
+	// From - DB type before change (empty for added column)
+	From string
+	// To - DB type after change
+	To string
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 )
@@ -9,12 +11,95 @@ import (
 // Repository - interface for data repositories
 type Repository interface {
 	Table() TableRepository
+	Profile() ProfileRepository
+	Import() ImportRepository
+	Script() ScriptRepository
+	DatabaseFile() DatabaseFileRepository
+	// Atomic - run fn in one transaction, all repository calls with ctx of fn use it
+	Atomic(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // TableRepository - interface for table operations
 type TableRepository interface {
 	// Create - create table
 	Create(ctx context.Context, table models.Table) error
+	// CreateNew - create table which must not exist, name is taken atomically
+	// (ErrTableExists if table, created by any tool, or concurrent creation has the name)
+	CreateNew(ctx context.Context, table models.Table) error
+	// PlanChanges - compare table with existing one, return changes required to append its data
+	// (ErrTableNotFound if table does not exist)
+	PlanChanges(ctx context.Context, table models.Table) ([]models.SchemaChange, error)
+	// ApplyChanges - add columns and widen types of existing table
+	ApplyChanges(ctx context.Context, table models.Table, changes []models.SchemaChange) error
 	// SaveData - save data in table
 	SaveData(ctx context.Context, table models.Table, data [][]string) error
+	// SaveDataTolerant - save data in table skipping failed rows (up to maxErrors),
+	// return failed rows with numbers of data rows (1-based)
+	SaveDataTolerant(ctx context.Context, table models.Table, data [][]string, maxErrors int) ([]models.RejectedRow, error)
+	// SaveRejects - save rejected rows of import in <table>_rejects
+	// (replace - recreate table, otherwise rows of earlier imports are kept)
+	SaveRejects(ctx context.Context, table models.Table, importID int64, replace bool, rejects []models.RejectedRow) error
+
+	// Register - mark table as created by service
+	Register(ctx context.Context, name string, importID int64) error
+	// List - list tables created by service
+	List(ctx context.Context, filter models.TableFilter) ([]models.TableInfo, error)
+	// Describe - get columns, row count and size of table created by service
+	Describe(ctx context.Context, name string) (models.TableInfo, error)
+	// Rename - rename table created by service
+	Rename(ctx context.Context, name, newName string) error
+	// Drop - drop table created by service
+	Drop(ctx context.Context, name string) error
+	// Rows - get page of rows of table created by service
+	Rows(ctx context.Context, name string, query models.RowsQuery) (models.RowsPage, error)
+	// Export - stream all rows of any table in current schema to w, return number of rows
+	Export(ctx context.Context, name string, w RowWriter) (int64, error)
+}
+
+// RowWriter - interface for writer of exported rows
+type RowWriter interface {
+	// WriteHeader - called once before rows
+	WriteHeader(columns []models.ColumnInfo) error
+	// WriteRow - values in order of columns (nil, int64, float64, bool, string, time.Time),
+	// slice is reused between calls
+	WriteRow(values []any) error
+}
+
+// ProfileRepository - interface for column profiles storage
+type ProfileRepository interface {
+	// Save - save table schema with column profiles
+	Save(ctx context.Context, table models.Table) error
+	// Get - get table schema with column profiles
+	Get(ctx context.Context, tableName string) (models.Table, error)
+}
+
+// ImportRepository - interface for imports catalog
+type ImportRepository interface {
+	// Create - save import record, return its id (imp.ID is used if it is reserved by NextID)
+	Create(ctx context.Context, imp models.Import) (int64, error)
+	// NextID - reserve id of import record before import is finished
+	NextID(ctx context.Context) (int64, error)
+	// LockKey - run fn holding lock of idempotency key, concurrent calls with the same key wait for it
+	LockKey(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	// FindByIdempotencyKey - get latest successful import with key made after since (ErrImportNotFound if none)
+	FindByIdempotencyKey(ctx context.Context, key string, since time.Time) (models.Import, error)
+	// FindByChecksum - get latest successful import of the same file to table made after since (ErrImportNotFound if none)
+	FindByChecksum(ctx context.Context, tableName, checksum string, since time.Time) (models.Import, error)
+	// List - get import records (newest first)
+	List(ctx context.Context, limit, offset int) ([]models.Import, error)
+	// Get - get import record by id
+	Get(ctx context.Context, id int64) (models.Import, error)
+}
+
+// ScriptRepository - interface for rendering tables as SQL script (offline, without DB)
+type ScriptRepository interface {
+	// WriteScript - write statements of dialect which create table and insert data rows to w
+	// (ErrUnsupportedDialect for unknown dialect)
+	WriteScript(ctx context.Context, w io.Writer, dialect models.SQLDialect, table models.Table, data [][]string) error
+}
+
+// DatabaseFileRepository - interface for building standalone database files (offline, without DB)
+type DatabaseFileRepository interface {
+	// WriteSQLite - write SQLite database file with tables, their rows and rejected rows (<table>_rejects) to w
+	WriteSQLite(ctx context.Context, w io.Writer, tables []models.TableData) error
 }
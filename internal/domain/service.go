@@ -10,6 +10,8 @@ import (
 const (
 	ExtXLSX = ".xlsx"
 	ExtCSV  = ".csv"
+	// ExtZIP - archive of CSV and XLSX files (union of files only)
+	ExtZIP = ".zip"
 )
 
 // Service - interface for buisness logic
@@ -17,11 +19,17 @@ type Service interface {
 	Parser() FileParserService
 	SchemaAnalyzer() SchemaAnalyzerService
 	Processor() ProcessorService
+	Imports() ImportService
+	Tables() TableService
+	Export() ExportService
+	Batches() BatchService
 }
 
 // FileParserService - interface for file parser buisness logic
 type FileParserService interface {
-	Parse(ctx context.Context, r io.Reader, extension string) ([][]string, error)
+	Parse(ctx context.Context, r io.Reader, extension string) (models.Sheet, error)
+	// ParseSheets - parse all sheets of workbook (CSV is one sheet without name)
+	ParseSheets(ctx context.Context, r io.Reader, extension string) ([]models.Sheet, error)
 }
 
 // SchemaAnalyzerService - interface for schema analyzer buisness logic
@@ -31,5 +39,54 @@ type SchemaAnalyzerService interface {
 
 // ProcessorService - interface for process manager
 type ProcessorService interface {
-	UploadFile(ctx context.Context, tableName string, file io.Reader, extension string) error
+	UploadFile(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions) (models.ImportResult, error)
+	// Preview - analyze file schema without writing to DB
+	Preview(ctx context.Context, tableName string, file io.Reader, extension string) (models.Table, error)
+	// Convert - analyze file and write SQL script (CREATE TABLE and INSERTs) to w without DB
+	Convert(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions, w io.Writer) (models.ImportResult, error)
+	// ConvertSQLite - analyze file and write SQLite database file (table per sheet) to w without DB
+	ConvertSQLite(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions, w io.Writer) ([]models.ImportResult, error)
+	// UploadUnion - load files (entries of .zip archives too) into one table: schemas are merged by column names
+	// (case-insensitive) with widened types, columns absent in file are NULL
+	UploadUnion(ctx context.Context, files []string, open FileOpener, opts models.UploadOptions) (models.UnionResult, error)
+	// Profile - return saved schema with column profiles of imported table
+	Profile(ctx context.Context, tableName string) (models.Table, error)
+}
+
+// ImportService - interface for imports catalog
+type ImportService interface {
+	List(ctx context.Context, limit, offset int) ([]models.Import, error)
+	Get(ctx context.Context, id int64) (models.Import, error)
+}
+
+// TableService - interface for management of tables created by service
+type TableService interface {
+	List(ctx context.Context, filter models.TableFilter) ([]models.TableInfo, error)
+	Describe(ctx context.Context, name string) (models.TableInfo, error)
+	Rename(ctx context.Context, name, newName string) error
+	Drop(ctx context.Context, name string) error
+	// Rows - return page of rows with selected columns and equality filters
+	Rows(ctx context.Context, name string, query models.RowsQuery) (models.RowsPage, error)
+}
+
+// ExportService - interface for export of tables to files
+type ExportService interface {
+	// Export - stream any table of DB to w as file with extension (ExtCSV, ExtXLSX), return number of rows
+	Export(ctx context.Context, tableName string, extension string, w io.Writer) (int64, error)
+	// WritePage - write page of rows to w as file with extension
+	WritePage(page models.RowsPage, extension string, w io.Writer) error
+}
+
+// FileOpener - open file of batch by its name in manifest
+type FileOpener func(name string) (io.ReadCloser, error)
+
+// BatchService - interface for import of many files described by manifest
+type BatchService interface {
+	// ParseManifest - read and validate YAML manifest (ErrInvalidManifest)
+	ParseManifest(r io.Reader) (models.BatchManifest, error)
+	// FilesManifest - return manifest of files imported with the same options
+	// (parallelism 0 - default of config, capped by config; ErrInvalidManifest if files go to the same table)
+	FilesManifest(files []string, opts models.UploadOptions, parallelism int, allOrNothing bool) (models.BatchManifest, error)
+	// Run - import files of manifest in order of dependencies, return consolidated report
+	Run(ctx context.Context, manifest models.BatchManifest, open FileOpener) (models.BatchReport, error)
 }
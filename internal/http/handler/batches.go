@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// CreateBatch godoc
+// @Summary Import many files described by a manifest
+// @Description Accepts YAML manifest and the files it lists (matched by file name). Files are imported with bounded parallelism in order of depends_on; with all_or_nothing they are imported one by one in one transaction and any failure rolls back the whole batch. Returns one consolidated report.
+// @Tags files
+// @Accept multipart/form-data
+// @Produce json
+// @Param manifest formData file true "YAML manifest: parallelism, all_or_nothing, defaults and files with file, name, table, mode, schema, on_conflict, strict, lineage, force, depends_on"
+// @Param file formData file true "File listed in manifest, repeat the field for every file"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} Response
+// @Failure 413 {object} Response
+// @Failure 422 {object} BatchResponse "Some files failed (report of every file)"
+// @Failure 500 {object} Response
+// @Router /batches [post]
+func (h *Handler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.CreateBatch"
+	log := h.log.With(slog.String("op", op))
+
+	if err := h.parseForm(w, r); err != nil {
+		h.sendError(w, formStatus(err), err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	manifestFile, _, err := r.FormFile("manifest")
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, errors.New("field 'manifest' is required"))
+		return
+	}
+	defer manifestFile.Close()
+
+	manifest, err := h.service.Batches().ParseManifest(manifestFile)
+	if err != nil {
+		log.Error("failed to parse manifest", slog.Any("err", err))
+		h.handleServiceError(w, err)
+		return
+	}
+
+	files := make(map[string]*multipart.FileHeader)
+	for _, header := range r.MultipartForm.File["file"] {
+		name := batchFileName(header.Filename)
+		if _, ok := files[name]; ok {
+			h.sendError(w, http.StatusBadRequest, fmt.Errorf("file %q is uploaded twice", name))
+			return
+		}
+		files[name] = header
+	}
+	for _, step := range manifest.Steps {
+		if _, ok := files[batchFileName(step.File)]; !ok {
+			h.sendError(w, http.StatusBadRequest, fmt.Errorf("file %q of manifest is not uploaded", step.File))
+			return
+		}
+	}
+
+	open := func(name string) (io.ReadCloser, error) {
+		return files[batchFileName(name)].Open()
+	}
+
+	// several files take longer than request timeout
+	clearWriteDeadline(w, log)
+
+	report, err := h.service.Batches().Run(r.Context(), manifest, open)
+	if err != nil {
+		log.Error("failed to run batch", slog.Any("err", err))
+		h.handleServiceError(w, err)
+		return
+	}
+
+	code := http.StatusOK
+	if report.Status != models.BatchStatusSuccess {
+		code = http.StatusUnprocessableEntity
+	}
+	h.sendJSON(w, code, newBatchResponse(report))
+}
+
+// batchFileName - return name of uploaded file for file of manifest (paths are written for CLI)
+func batchFileName(name string) string {
+	return path.Base(filepath.ToSlash(name))
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+)
+
+// ExportTable godoc
+// @Summary Export a table
+// @Description Streams any table of allowed schemas (not only created by the service) as CSV or XLSX file.
+// @Description Service tables (imports, table_profiles, ...) can not be exported.
+// @Description Format is chosen by 'format' param or Accept header, CSV by default.
+// @Description XLSX keeps numbers, booleans and dates as native cells; text cells of CSV starting with =, +, -, @ are prefixed with '.
+// @Tags export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Param format query string false "File format" Enums(csv, xlsx)
+// @Success 200 {file} file
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 422 {object} Response
+// @Failure 500 {object} Response
+// @Router /export/{name} [get]
+func (h *Handler) ExportTable(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.ExportTable"
+	log := h.log.With(slog.String("op", op))
+
+	extension := domain.ExtCSV
+	if format := r.URL.Query().Get("format"); format != "" {
+		extension = "." + strings.ToLower(format)
+	} else if ext := negotiateFormat(r.Header.Get("Accept")); ext != "" {
+		extension = ext
+	}
+
+	name := r.PathValue("name")
+	aw := newAttachmentWriter(w, name+extension)
+	clearWriteDeadline(w, log)
+
+	if _, err := h.service.Export().Export(r.Context(), name, extension, aw); err != nil {
+		log.Error("failed to export table", slog.Any("err", err))
+
+		if !aw.started {
+			h.handleServiceError(w, err)
+			return
+		}
+		// file is partially sent, abort response so client does not get truncated file as complete
+		panic(http.ErrAbortHandler)
+	}
+}
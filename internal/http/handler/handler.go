@@ -3,23 +3,44 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 )
 
+// maxIdempotencyKeyLen - max length of Idempotency-Key header
+const maxIdempotencyKeyLen = 255
+
+// Outputs of upload
+const (
+	// outputDB - load file to DB of service
+	outputDB = "db"
+	// outputSQLite - return file converted to SQLite database as download, DB of service is not used
+	outputSQLite = "sqlite"
+)
+
+// extSQLite - extension of downloaded SQLite database
+const extSQLite = ".sqlite"
+
 // Handler - struct for handler
 type Handler struct {
 	service domain.Service
-	log     *slog.Logger
+	// maxUploadSize - max size of request body with files
+	maxUploadSize int64
+	log           *slog.Logger
 }
 
 // NewHandler - constructor for handler
-func NewHandler(service domain.Service, log *slog.Logger) *Handler {
-	return &Handler{service: service, log: log}
+func NewHandler(service domain.Service, maxUploadSize int64, log *slog.Logger) *Handler {
+	return &Handler{service: service, maxUploadSize: maxUploadSize, log: log}
 }
 
 // Response - struct for response
@@ -31,12 +52,33 @@ type Response struct {
 // UploadFile godoc
 // @Summary Upload a file and create a table
 // @Description Accepts .csv or .xlsx, analyzes structure, creates a table in PG and inserts data.
+// @Description Several 'file' fields are imported concurrently (up to 'parallelism') with the same options, response is BatchResponse with status of every file (422 if not all files are imported);
+// @Description with all_or_nothing files are imported one by one in one transaction. Files whose names give the same table are rejected (400).
+// @Description With union=true all files (and CSV/XLSX entries of .zip archives) are loaded to one table in one transaction, response is UnionResponse:
+// @Description columns are matched by names case-insensitively, types are widened, columns absent in file are NULL.
 // @Tags files
 // @Accept multipart/form-data
 // @Produce json
-// @Param file formData file true "CSV or XLSX file"
-// @Success 200 {object} Response
+// @Param file formData file true "CSV or XLSX file (or .zip with union), repeat the field to upload several files"
+// @Param parallelism formData int false "Several files: max files imported at the same time (default and max import.batch_parallelism)"
+// @Param all_or_nothing formData bool false "Several files: import in one transaction, any failure rolls back all files"
+// @Param union formData bool false "Load all files (and entries of .zip archives) to one table with merged schema"
+// @Param source_column formData string false "Union: name of column with source file of every row (default no column)"
+// @Param mode formData string false "What to do with existing table: replace (default) or append with adding columns and widening types" Enums(replace, append)
+// @Param strict formData bool false "Reject append if existing table schema differs from file"
+// @Param schema formData string false "Postgres schema of table, must be in allowed_schemas (default search_path)"
+// @Param table formData string false "Table name (default derived from filename), may contain date placeholders like sales_{yyyy_mm}"
+// @Param lineage formData bool false "Add lineage columns _import_id, _source_file, _source_sheet, _source_row_number, _loaded_at"
+// @Param Idempotency-Key header string false "Key of request, repeated request with the same key and file returns original result"
+// @Param force formData bool false "Append file even if the same file is already appended to table (request without Idempotency-Key)"
+// @Param on_conflict formData string false "What to do if table exists in replace mode: overwrite (default) or suffix with _v2, _v3, ..." Enums(overwrite, suffix)
+// @Param output formData string false "db (default) loads file to DB, sqlite returns .sqlite database file (table per sheet, rejected rows in <table>_rejects) without touching DB" Enums(db, sqlite)
+// @Produce application/vnd.sqlite3
+// @Success 200 {object} UploadResponse
 // @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 409 {object} Response
+// @Failure 413 {object} Response
 // @Failure 422 {object} Response
 // @Failure 500 {object} Response
 // @Router /upload [post]
@@ -49,22 +91,69 @@ func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(20 << 20); err != nil {
-		log.Error("failed to parse form", slog.Any("err", err))
-		h.sendError(w, http.StatusBadRequest, errors.New("file is too large or invalid form"))
+	file, header, err := h.formFile(w, r)
+	if err != nil {
+		log.Error("failed to read form file", slog.Any("err", err))
+		h.sendError(w, formStatus(err), err)
 		return
 	}
+	defer file.Close()
 
-	file, header, err := r.FormFile("file")
+	opts, err := uploadOptions(r)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, errors.New("field 'file' is required"))
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	if len(opts.IdempotencyKey) > maxIdempotencyKeyLen {
+		h.sendError(w, http.StatusBadRequest, fmt.Errorf("Idempotency-Key must be at most %d bytes", maxIdempotencyKeyLen))
 		return
 	}
-	defer file.Close()
 
 	ext := strings.ToLower(filepath.Ext(header.Filename))
 
-	err = h.service.Processor().UploadFile(r.Context(), header.Filename, file, ext)
+	headers := r.MultipartForm.File["file"]
+
+	var union bool
+	if v := r.FormValue("union"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, errors.New("union must be true or false"))
+			return
+		}
+		union = b
+	}
+	if opts.SourceColumn != "" && !union {
+		h.sendError(w, http.StatusBadRequest, errors.New("source_column can be set only with union=true"))
+		return
+	}
+
+	switch output := r.FormValue("output"); output {
+	case "", outputDB:
+	case outputSQLite:
+		if len(headers) > 1 || union {
+			h.sendError(w, http.StatusBadRequest, fmt.Errorf("output=%s accepts one file without union", outputSQLite))
+			return
+		}
+		h.uploadSQLite(w, r, file, header.Filename, ext, opts)
+		return
+	default:
+		h.sendError(w, http.StatusBadRequest, fmt.Errorf("output must be %s or %s", outputDB, outputSQLite))
+		return
+	}
+
+	if union {
+		h.uploadUnion(w, r, headers, opts)
+		return
+	}
+
+	if len(headers) > 1 {
+		h.uploadFiles(w, r, headers, opts)
+		return
+	}
+
+	result, err := h.service.Processor().UploadFile(r.Context(), header.Filename, file, ext, opts)
 	if err != nil {
 		log.Error("failed to process file", slog.Any("err", err))
 
@@ -72,7 +161,323 @@ func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.sendJSON(w, http.StatusOK, Response{Status: "OK"})
+	h.sendJSON(w, http.StatusOK, newUploadResponse(result))
+}
+
+// uploadFiles - import several files with the same options as batch, send status of every file
+func (h *Handler) uploadFiles(w http.ResponseWriter, r *http.Request, headers []*multipart.FileHeader, opts models.UploadOptions) {
+	const op = "delivery.http.uploadFiles"
+	log := h.log.With(slog.String("op", op))
+
+	defer r.MultipartForm.RemoveAll()
+
+	if opts.Table != "" {
+		h.sendError(w, http.StatusBadRequest, errors.New("table can not be set for several files, table names are derived from file names (union=true loads files to one table)"))
+		return
+	}
+
+	var parallelism int
+	if v := r.FormValue("parallelism"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			h.sendError(w, http.StatusBadRequest, errors.New("parallelism must be non-negative"))
+			return
+		}
+		parallelism = p
+	}
+
+	var allOrNothing bool
+	if v := r.FormValue("all_or_nothing"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, errors.New("all_or_nothing must be true or false"))
+			return
+		}
+		allOrNothing = b
+	}
+
+	files := make(map[string]*multipart.FileHeader, len(headers))
+	names := make([]string, 0, len(headers))
+	for _, header := range headers {
+		name := batchFileName(header.Filename)
+		if _, ok := files[name]; ok {
+			h.sendError(w, http.StatusBadRequest, fmt.Errorf("file %q is uploaded twice", name))
+			return
+		}
+		files[name] = header
+		names = append(names, name)
+	}
+
+	manifest, err := h.service.Batches().FilesManifest(names, opts, parallelism, allOrNothing)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	open := func(name string) (io.ReadCloser, error) {
+		return files[name].Open()
+	}
+
+	// several files take longer than request timeout
+	clearWriteDeadline(w, log)
+
+	report, err := h.service.Batches().Run(r.Context(), manifest, open)
+	if err != nil {
+		log.Error("failed to upload files", slog.Any("err", err))
+		h.handleServiceError(w, err)
+		return
+	}
+
+	code := http.StatusOK
+	if report.Status != models.BatchStatusSuccess {
+		code = http.StatusUnprocessableEntity
+	}
+	h.sendJSON(w, code, newBatchResponse(report))
+}
+
+// uploadUnion - load all uploaded files (and entries of .zip archives) to one table
+func (h *Handler) uploadUnion(w http.ResponseWriter, r *http.Request, headers []*multipart.FileHeader, opts models.UploadOptions) {
+	const op = "delivery.http.uploadUnion"
+	log := h.log.With(slog.String("op", op))
+
+	defer r.MultipartForm.RemoveAll()
+
+	files := make(map[string]*multipart.FileHeader, len(headers))
+	names := make([]string, 0, len(headers))
+	for _, header := range headers {
+		name := batchFileName(header.Filename)
+		if _, ok := files[name]; ok {
+			h.sendError(w, http.StatusBadRequest, fmt.Errorf("file %q is uploaded twice", name))
+			return
+		}
+		files[name] = header
+		names = append(names, name)
+	}
+
+	open := func(name string) (io.ReadCloser, error) {
+		return files[name].Open()
+	}
+
+	// several files take longer than request timeout
+	clearWriteDeadline(w, log)
+
+	result, err := h.service.Processor().UploadUnion(r.Context(), names, open, opts)
+	if err != nil {
+		log.Error("failed to load files to one table", slog.Any("err", err))
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newUnionResponse(result))
+}
+
+// uploadSQLite - send file converted to SQLite database (written at once when all sheets are converted)
+func (h *Handler) uploadSQLite(w http.ResponseWriter, r *http.Request, file io.Reader, filename, ext string, opts models.UploadOptions) {
+	const op = "delivery.http.uploadSQLite"
+	log := h.log.With(slog.String("op", op))
+
+	aw := newAttachmentWriter(w, strings.TrimSuffix(filename, filepath.Ext(filename))+extSQLite)
+	clearWriteDeadline(w, log)
+
+	if _, err := h.service.Processor().ConvertSQLite(r.Context(), filename, file, ext, opts, aw); err != nil {
+		log.Error("failed to convert file to SQLite", slog.Any("err", err))
+
+		if !aw.started {
+			h.handleServiceError(w, err)
+			return
+		}
+		// file is partially sent, abort response so client does not get truncated file as complete
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Preview godoc
+// @Summary Preview a file schema
+// @Description Accepts .csv or .xlsx and returns the detected table schema without creating a table.
+// @Tags files
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Success 200 {object} SchemaResponse
+// @Failure 400 {object} Response
+// @Failure 413 {object} Response
+// @Failure 422 {object} Response
+// @Failure 500 {object} Response
+// @Router /preview [post]
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.Preview"
+	log := h.log.With(slog.String("op", op))
+
+	if r.Method != http.MethodPost {
+		h.sendError(w, http.StatusMethodNotAllowed, errors.New("only POST method is allowed"))
+		return
+	}
+
+	file, header, err := h.formFile(w, r)
+	if err != nil {
+		log.Error("failed to read form file", slog.Any("err", err))
+		h.sendError(w, formStatus(err), err)
+		return
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+
+	table, err := h.service.Processor().Preview(r.Context(), header.Filename, file, ext)
+	if err != nil {
+		log.Error("failed to preview file", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newSchemaResponse(table))
+}
+
+// TableProfile godoc
+// @Summary Get column profiles of a table
+// @Description Returns the schema and column statistics saved at upload of the table.
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Success 200 {object} SchemaResponse
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables/{name}/profile [get]
+func (h *Handler) TableProfile(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.TableProfile"
+	log := h.log.With(slog.String("op", op))
+
+	table, err := h.service.Processor().Profile(r.Context(), r.PathValue("name"))
+	if err != nil {
+		log.Error("failed to get profile", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newSchemaResponse(table))
+}
+
+// formFile - parse multipart form and return field 'file'
+func (h *Handler) formFile(w http.ResponseWriter, r *http.Request) (multipart.File, *multipart.FileHeader, error) {
+	if err := h.parseForm(w, r); err != nil {
+		return nil, nil, err
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, nil, errors.New("field 'file' is required")
+	}
+
+	return file, header, nil
+}
+
+// parseForm - parse multipart form, body is limited by maxUploadSize (files above 20 MB are kept on disk)
+func (h *Handler) parseForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+
+	if err := r.ParseMultipartForm(20 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("request body is larger than %d bytes: %w", tooLarge.Limit, domain.ErrFileTooLarge)
+		}
+		return errors.New("invalid form")
+	}
+
+	return nil
+}
+
+// formStatus - status of failed form parsing
+func formStatus(err error) int {
+	if errors.Is(err, domain.ErrFileTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// uploadOptions - parse 'mode', 'strict', 'schema', 'table', 'on_conflict', 'lineage', 'force' and 'source_column' form fields
+func uploadOptions(r *http.Request) (models.UploadOptions, error) {
+	opts := models.UploadOptions{
+		Mode:         models.UploadModeReplace,
+		Schema:       r.FormValue("schema"),
+		Table:        r.FormValue("table"),
+		OnConflict:   models.ConflictOverwrite,
+		SourceColumn: r.FormValue("source_column"),
+	}
+
+	switch mode := models.UploadMode(r.FormValue("mode")); mode {
+	case "", models.UploadModeReplace:
+	case models.UploadModeAppend:
+		opts.Mode = mode
+	default:
+		return models.UploadOptions{}, fmt.Errorf("mode must be %s or %s", models.UploadModeReplace, models.UploadModeAppend)
+	}
+
+	if v := r.FormValue("strict"); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return models.UploadOptions{}, errors.New("strict must be true or false")
+		}
+		opts.Strict = strict
+	}
+
+	if v := r.FormValue("lineage"); v != "" {
+		lineage, err := strconv.ParseBool(v)
+		if err != nil {
+			return models.UploadOptions{}, errors.New("lineage must be true or false")
+		}
+		opts.Lineage = lineage
+	}
+
+	if v := r.FormValue("force"); v != "" {
+		force, err := strconv.ParseBool(v)
+		if err != nil {
+			return models.UploadOptions{}, errors.New("force must be true or false")
+		}
+		opts.Force = force
+	}
+
+	switch policy := models.ConflictPolicy(r.FormValue("on_conflict")); policy {
+	case "", models.ConflictOverwrite:
+	case models.ConflictSuffix:
+		if opts.Mode == models.UploadModeAppend {
+			return models.UploadOptions{}, fmt.Errorf("on_conflict=%s can not be used with mode=%s", policy, opts.Mode)
+		}
+		opts.OnConflict = policy
+	default:
+		return models.UploadOptions{}, fmt.Errorf("on_conflict must be %s or %s", models.ConflictOverwrite, models.ConflictSuffix)
+	}
+
+	return opts, nil
+}
+
+// parsePagination - parse 'limit' and 'offset' query params
+func parsePagination(r *http.Request) (int, int, error) {
+	const (
+		defaultLimit = 50
+		maxLimit     = 1000
+	)
+
+	limit, offset := defaultLimit, 0
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be from 1 to %d", maxLimit)
+		}
+		limit = l
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			return 0, 0, errors.New("offset must be non-negative")
+		}
+		offset = o
+	}
+
+	return limit, offset, nil
 }
 
 func (h *Handler) sendError(w http.ResponseWriter, code int, err error) {
@@ -96,6 +501,55 @@ func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
 	case errors.Is(err, domain.ErrEmptyData), errors.Is(err, domain.ErrNoColumns):
 		h.sendError(w, http.StatusBadRequest, domain.ErrNoColumns)
 
+	case errors.Is(err, domain.ErrTooManyErrors):
+		h.sendError(w, http.StatusUnprocessableEntity, domain.ErrTooManyErrors)
+
+	case errors.Is(err, domain.ErrTableNotFound):
+		h.sendError(w, http.StatusNotFound, domain.ErrTableNotFound)
+
+	case errors.Is(err, domain.ErrImportNotFound):
+		h.sendError(w, http.StatusNotFound, domain.ErrImportNotFound)
+
+	case errors.Is(err, domain.ErrSchemaDrift):
+		var drift *domain.SchemaDriftError
+		if errors.As(err, &drift) {
+			h.sendError(w, http.StatusConflict, drift)
+			return
+		}
+		h.sendError(w, http.StatusConflict, domain.ErrSchemaDrift)
+
+	case errors.Is(err, domain.ErrTableExists):
+		h.sendError(w, http.StatusConflict, domain.ErrTableExists)
+
+	case errors.Is(err, domain.ErrInvalidTableName):
+		h.sendError(w, http.StatusBadRequest, domain.ErrInvalidTableName)
+
+	case errors.Is(err, domain.ErrColumnNotFound):
+		h.sendError(w, http.StatusBadRequest, domain.ErrColumnNotFound)
+
+	case errors.Is(err, domain.ErrInvalidColumnName):
+		h.sendError(w, http.StatusBadRequest, domain.ErrInvalidColumnName)
+
+	case errors.Is(err, domain.ErrInvalidCursor):
+		h.sendError(w, http.StatusBadRequest, domain.ErrInvalidCursor)
+
+	case errors.Is(err, domain.ErrInvalidSchema):
+		h.sendError(w, http.StatusBadRequest, domain.ErrInvalidSchema)
+
+	case errors.Is(err, domain.ErrSchemaNotAllowed):
+		h.sendError(w, http.StatusForbidden, domain.ErrSchemaNotAllowed)
+
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		h.sendError(w, http.StatusUnprocessableEntity, domain.ErrIdempotencyKeyReused)
+
+	case errors.Is(err, domain.ErrFileTooLarge):
+		// limit of archive is needed to fix request
+		h.sendError(w, http.StatusRequestEntityTooLarge, err)
+
+	case errors.Is(err, domain.ErrInvalidManifest):
+		// details of manifest error are needed to fix it
+		h.sendError(w, http.StatusBadRequest, err)
+
 	case errors.Is(err, http.ErrAbortHandler):
 		return
 
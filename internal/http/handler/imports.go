@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// ListImports godoc
+// @Summary List imports
+// @Description Returns records of imported files from the imports catalog, newest first.
+// @Tags imports
+// @Produce json
+// @Param limit query int false "Max number of records (default 50, max 1000)"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {object} ImportListResponse
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /imports [get]
+func (h *Handler) ListImports(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.ListImports"
+	log := h.log.With(slog.String("op", op))
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	imports, err := h.service.Imports().List(r.Context(), limit, offset)
+	if err != nil {
+		log.Error("failed to list imports", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newImportListResponse(imports, limit, offset))
+}
+
+// GetImport godoc
+// @Summary Get import
+// @Description Returns a record of imported file with inferred schema.
+// @Tags imports
+// @Produce json
+// @Param id path int true "Import id"
+// @Success 200 {object} ImportDetailResponse
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /imports/{id} [get]
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.GetImport"
+	log := h.log.With(slog.String("op", op))
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, errors.New("invalid import id"))
+		return
+	}
+
+	imp, err := h.service.Imports().Get(r.Context(), id)
+	if err != nil {
+		log.Error("failed to get import", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newImportDetailResponse(imp))
+}
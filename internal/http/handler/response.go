@@ -0,0 +1,389 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// maxRejectsInResponse - max rejected rows listed in upload response (all are saved in <table>_rejects)
+const maxRejectsInResponse = 100
+
+// ColumnResponse - struct for column in schema response
+type ColumnResponse struct {
+	Name       string               `json:"name"`
+	Source     string               `json:"source"`
+	Type       string               `json:"type"`
+	EnumValues []string             `json:"enum_values,omitempty"`
+	Confidence float64              `json:"confidence"`
+	Downgrades []TypeChangeResponse `json:"downgrades,omitempty"`
+	Profile    ProfileResponse      `json:"profile"`
+}
+
+// TypeChangeResponse - struct for value which forced column type change
+type TypeChangeResponse struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value string `json:"value"`
+	Row   int    `json:"row"`
+}
+
+// ProfileResponse - struct for column statistics
+type ProfileResponse struct {
+	NullCount     int                  `json:"null_count"`
+	DistinctCount int                  `json:"distinct_count"`
+	Min           string               `json:"min,omitempty"`
+	Max           string               `json:"max,omitempty"`
+	Mean          *float64             `json:"mean,omitempty"`
+	MaxLength     int                  `json:"max_length"`
+	TopValues     []ValueCountResponse `json:"top_values"`
+	Samples       []string             `json:"samples"`
+}
+
+// ValueCountResponse - struct for value with number of occurrences
+type ValueCountResponse struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SchemaResponse - struct for schema response
+type SchemaResponse struct {
+	Response
+	Table   string           `json:"table"`
+	Columns []ColumnResponse `json:"columns"`
+}
+
+// UploadResponse - struct for upload response
+type UploadResponse struct {
+	SchemaResponse
+	ImportID int64            `json:"import_id,omitempty"`
+	Rows     int              `json:"rows"`
+	Rejected int              `json:"rejected"`
+	Rejects  []RejectResponse `json:"rejects,omitempty"`
+	// Changes - changes of existing table made in append mode
+	Changes []SchemaChangeResponse `json:"changes,omitempty"`
+	// Replayed - upload repeats import ImportID, nothing was loaded
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// SchemaChangeResponse - struct for change of existing table
+type SchemaChangeResponse struct {
+	Kind   string `json:"kind"`
+	Column string `json:"column"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+}
+
+// RejectResponse - struct for row which was not loaded
+type RejectResponse struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func newSchemaResponse(table models.Table) SchemaResponse {
+	columns := make([]ColumnResponse, len(table.Columns))
+	for i, col := range table.Columns {
+		downgrades := make([]TypeChangeResponse, len(col.Downgrades))
+		for j, d := range col.Downgrades {
+			downgrades[j] = TypeChangeResponse{From: d.From.String(), To: d.To.String(), Value: d.Value, Row: d.Row}
+		}
+
+		columns[i] = ColumnResponse{
+			Name:       col.Name,
+			Source:     col.Source,
+			Type:       col.Type.String(),
+			EnumValues: col.EnumValues,
+			Confidence: col.Confidence,
+			Downgrades: downgrades,
+			Profile:    newProfileResponse(col.Profile),
+		}
+	}
+
+	return SchemaResponse{
+		Response: Response{Status: "OK"},
+		Table:    table.Name,
+		Columns:  columns,
+	}
+}
+
+func newProfileResponse(p models.ColumnProfile) ProfileResponse {
+	top := make([]ValueCountResponse, len(p.TopValues))
+	for i, v := range p.TopValues {
+		top[i] = ValueCountResponse{Value: v.Value, Count: v.Count}
+	}
+
+	samples := p.Samples
+	if samples == nil {
+		samples = []string{}
+	}
+
+	return ProfileResponse{
+		NullCount:     p.NullCount,
+		DistinctCount: p.DistinctCount,
+		Min:           p.Min,
+		Max:           p.Max,
+		Mean:          p.Mean,
+		MaxLength:     p.MaxLength,
+		TopValues:     top,
+		Samples:       samples,
+	}
+}
+
+func newUploadResponse(result models.ImportResult) UploadResponse {
+	rejects := result.Rejects
+	if len(rejects) > maxRejectsInResponse {
+		rejects = rejects[:maxRejectsInResponse]
+	}
+
+	resp := UploadResponse{
+		SchemaResponse: newSchemaResponse(result.Table),
+		ImportID:       result.ImportID,
+		Rows:           result.Rows,
+		Rejected:       result.Rejected,
+		Rejects:        make([]RejectResponse, len(rejects)),
+		Replayed:       result.Replayed,
+	}
+	for i, r := range rejects {
+		resp.Rejects[i] = RejectResponse{Row: r.Row, Column: r.Column, Value: r.Value, Reason: r.Reason}
+	}
+	for _, c := range result.Changes {
+		resp.Changes = append(resp.Changes, SchemaChangeResponse{Kind: string(c.Kind), Column: c.Column, From: c.From, To: c.To})
+	}
+
+	return resp
+}
+
+// ImportResponse - struct for import record
+type ImportResponse struct {
+	ID           int64            `json:"id"`
+	Filename     string           `json:"filename"`
+	Table        string           `json:"table"`
+	Checksum     string           `json:"checksum"`
+	Size         int64            `json:"size"`
+	Rows         int              `json:"rows"`
+	Columns      int              `json:"columns"`
+	Rejected     int              `json:"rejected"`
+	Schema       []ColumnResponse `json:"schema,omitempty"`
+	DurationMs   int64            `json:"duration_ms"`
+	ImportStatus string           `json:"import_status"`
+	ImportError  string           `json:"import_error,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// ImportDetailResponse - struct for import record with inferred schema
+type ImportDetailResponse struct {
+	Response
+	ImportResponse
+}
+
+// ImportListResponse - struct for list of import records
+type ImportListResponse struct {
+	Response
+	Imports []ImportResponse `json:"imports"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+}
+
+func newImportResponse(imp models.Import) ImportResponse {
+	return ImportResponse{
+		ID:           imp.ID,
+		Filename:     imp.Filename,
+		Table:        imp.TableName,
+		Checksum:     imp.Checksum,
+		Size:         imp.Size,
+		Rows:         imp.Rows,
+		Columns:      imp.Columns,
+		Rejected:     imp.Rejected,
+		DurationMs:   imp.Duration.Milliseconds(),
+		ImportStatus: string(imp.Status),
+		ImportError:  imp.Error,
+		CreatedAt:    imp.CreatedAt,
+	}
+}
+
+func newImportDetailResponse(imp models.Import) ImportDetailResponse {
+	item := newImportResponse(imp)
+	item.Schema = newSchemaResponse(imp.Schema).Columns
+
+	return ImportDetailResponse{
+		Response:       Response{Status: "OK"},
+		ImportResponse: item,
+	}
+}
+
+func newImportListResponse(imports []models.Import, limit, offset int) ImportListResponse {
+	resp := ImportListResponse{
+		Response: Response{Status: "OK"},
+		Imports:  make([]ImportResponse, len(imports)),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	for i, imp := range imports {
+		resp.Imports[i] = newImportResponse(imp)
+	}
+	return resp
+}
+
+// TableResponse - struct for table created by service
+type TableResponse struct {
+	Schema    string                `json:"schema"`
+	Name      string                `json:"name"`
+	Columns   []TableColumnResponse `json:"columns,omitempty"`
+	Rows      int64                 `json:"rows"`
+	SizeBytes int64                 `json:"size_bytes"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// TableColumnResponse - struct for column of existing table (type as in DB)
+type TableColumnResponse struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TableDetailResponse - struct for table with columns and exact row count
+type TableDetailResponse struct {
+	Response
+	TableResponse
+}
+
+// TableListResponse - struct for list of tables (rows are estimated)
+type TableListResponse struct {
+	Response
+	Tables []TableResponse `json:"tables"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// RenameTableRequest - struct for rename table request
+type RenameTableRequest struct {
+	Name string `json:"name"`
+}
+
+func newTableResponse(info models.TableInfo) TableResponse {
+	resp := TableResponse{
+		Schema:    info.Schema,
+		Name:      info.Name,
+		Rows:      info.Rows,
+		SizeBytes: info.SizeBytes,
+		CreatedAt: info.CreatedAt,
+		UpdatedAt: info.UpdatedAt,
+	}
+	if len(info.Columns) > 0 {
+		resp.Columns = make([]TableColumnResponse, len(info.Columns))
+		for i, col := range info.Columns {
+			resp.Columns[i] = TableColumnResponse{Name: col.Name, Type: col.Type}
+		}
+	}
+	return resp
+}
+
+func newTableListResponse(tables []models.TableInfo, limit, offset int) TableListResponse {
+	resp := TableListResponse{
+		Response: Response{Status: "OK"},
+		Tables:   make([]TableResponse, len(tables)),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	for i, t := range tables {
+		resp.Tables[i] = newTableResponse(t)
+	}
+	return resp
+}
+
+// RowsResponse - struct for page of table rows
+type RowsResponse struct {
+	Response
+	Columns []TableColumnResponse `json:"columns"`
+	// Rows - values in order of columns
+	Rows       [][]any `json:"rows"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset,omitempty"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func newRowsResponse(page models.RowsPage, query models.RowsQuery) RowsResponse {
+	resp := RowsResponse{
+		Response:   Response{Status: "OK"},
+		Columns:    make([]TableColumnResponse, len(page.Columns)),
+		Rows:       page.Rows,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+		NextCursor: page.NextCursor,
+	}
+	for i, col := range page.Columns {
+		resp.Columns[i] = TableColumnResponse{Name: col.Name, Type: col.Type}
+	}
+	return resp
+}
+
+// BatchResponse - struct for consolidated report of batch
+type BatchResponse struct {
+	// Status - success, partial (some files failed) or failed (all failed, or all-or-nothing batch rolled back)
+	Status     string              `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	Files      []BatchFileResponse `json:"files"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// BatchFileResponse - struct for result of file of batch
+type BatchFileResponse struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	// Status - success, failed, skipped (dependency failed) or rolled_back
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	Result     *UploadResponse `json:"result,omitempty"`
+}
+
+func newBatchResponse(report models.BatchReport) BatchResponse {
+	resp := BatchResponse{
+		Status:     string(report.Status),
+		Error:      report.Error,
+		Files:      make([]BatchFileResponse, len(report.Steps)),
+		DurationMs: report.Duration.Milliseconds(),
+	}
+	for i, step := range report.Steps {
+		resp.Files[i] = BatchFileResponse{
+			Name:       step.Name,
+			File:       step.File,
+			Status:     string(step.Status),
+			Error:      step.Error,
+			DurationMs: step.Duration.Milliseconds(),
+		}
+		if step.Status == models.BatchStatusSuccess || step.Status == models.BatchStatusRolledBack {
+			result := newUploadResponse(step.Result)
+			resp.Files[i].Result = &result
+		}
+	}
+	return resp
+}
+
+// UnionResponse - struct for result of loading several files into one table
+type UnionResponse struct {
+	UploadResponse
+	Files []UnionFileResponse `json:"files,omitempty"`
+}
+
+// UnionFileResponse - struct for file loaded into union table
+type UnionFileResponse struct {
+	Name     string `json:"name"`
+	Rows     int    `json:"rows"`
+	Rejected int    `json:"rejected"`
+	// Missing - columns of table absent in file (NULL in its rows)
+	Missing []string `json:"missing,omitempty"`
+}
+
+func newUnionResponse(result models.UnionResult) UnionResponse {
+	resp := UnionResponse{
+		UploadResponse: newUploadResponse(result.ImportResult),
+		Files:          make([]UnionFileResponse, len(result.Files)),
+	}
+	for i, f := range result.Files {
+		resp.Files[i] = UnionFileResponse{Name: f.Name, Rows: f.Rows, Rejected: f.Rejected, Missing: f.Missing}
+	}
+	return resp
+}
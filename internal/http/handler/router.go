@@ -9,6 +9,17 @@ import (
 // RegisterRoutes - register routes for http
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/upload", h.Upload)
+	mux.HandleFunc("/preview", h.Preview)
+	mux.HandleFunc("POST /batches", h.CreateBatch)
+	mux.HandleFunc("GET /tables", h.ListTables)
+	mux.HandleFunc("GET /tables/{name}", h.DescribeTable)
+	mux.HandleFunc("PATCH /tables/{name}", h.RenameTable)
+	mux.HandleFunc("DELETE /tables/{name}", h.DropTable)
+	mux.HandleFunc("GET /tables/{name}/profile", h.TableProfile)
+	mux.HandleFunc("GET /tables/{name}/rows", h.TableRows)
+	mux.HandleFunc("GET /export/{name}", h.ExportTable)
+	mux.HandleFunc("GET /imports", h.ListImports)
+	mux.HandleFunc("GET /imports/{id}", h.GetImport)
 	// swagger docs http://localhost:8080/swagger/index.html.
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 }
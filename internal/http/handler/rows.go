@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// Media types of table rows
+const (
+	mimeJSON   = "application/json"
+	mimeCSV    = "text/csv"
+	mimeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimeSQLite = "application/vnd.sqlite3"
+)
+
+// TableRows godoc
+// @Summary Get rows of a table
+// @Description Returns a page of rows of a table created by the service, ordered by physical position.
+// @Description Format is chosen by Accept header: application/json (default), text/csv or
+// @Description application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.
+// @Description For CSV and XLSX the cursor of the next page is returned in X-Next-Cursor header.
+// @Description Cursor is physical position of row (ctid in PostgreSQL, rowid in SQLite) and is best-effort:
+// @Description rows moved by UPDATE or VACUUM FULL between pages may be skipped or repeated.
+// @Tags tables
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Param columns query string false "Comma-separated columns to return (default all)"
+// @Param filter query []string false "Equality filter column:value, compared as text" collectionFormat(multi)
+// @Param limit query int false "Max number of rows (default 50, max 1000)"
+// @Param offset query int false "Number of rows to skip"
+// @Param after query string false "Cursor of next page (keyset pagination, instead of offset)"
+// @Success 200 {object} RowsResponse
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables/{name}/rows [get]
+func (h *Handler) TableRows(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.TableRows"
+	log := h.log.With(slog.String("op", op))
+
+	query, err := parseRowsQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	page, err := h.service.Tables().Rows(r.Context(), name, query)
+	if err != nil {
+		log.Error("failed to get rows", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	extension := negotiateFormat(r.Header.Get("Accept"))
+	if extension == "" {
+		h.sendJSON(w, http.StatusOK, newRowsResponse(page, query))
+		return
+	}
+
+	aw := newAttachmentWriter(w, name+extension)
+	aw.nextCursor = page.NextCursor
+	if err := h.service.Export().WritePage(page, extension, aw); err != nil {
+		log.Error("failed to write rows", slog.Any("err", err))
+
+		if !aw.started {
+			h.handleServiceError(w, err)
+		}
+	}
+}
+
+// parseRowsQuery - parse 'columns', 'filter', 'after' and pagination query params
+func parseRowsQuery(r *http.Request) (models.RowsQuery, error) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		return models.RowsQuery{}, err
+	}
+
+	values := r.URL.Query()
+	query := models.RowsQuery{Limit: limit, Offset: offset, After: values.Get("after")}
+
+	if query.After != "" && offset > 0 {
+		return models.RowsQuery{}, errors.New("after and offset can not be used together")
+	}
+
+	if v := values.Get("columns"); v != "" {
+		for _, col := range strings.Split(v, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				query.Columns = append(query.Columns, col)
+			}
+		}
+	}
+
+	for _, f := range values["filter"] {
+		col, val, ok := strings.Cut(f, ":")
+		if !ok || col == "" {
+			return models.RowsQuery{}, fmt.Errorf("filter %q must be column:value", f)
+		}
+		query.Filters = append(query.Filters, models.Filter{Column: col, Value: val})
+	}
+
+	return query, nil
+}
+
+// negotiateFormat - return extension of first supported file media type from Accept header ("" - JSON)
+func negotiateFormat(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case mimeJSON:
+			return ""
+		case mimeCSV:
+			return domain.ExtCSV
+		case mimeXLSX:
+			return domain.ExtXLSX
+		}
+	}
+	return ""
+}
+
+// attachmentWriter - set headers of downloaded file on first write,
+// so errors before it can still be sent as JSON
+type attachmentWriter struct {
+	w          http.ResponseWriter
+	filename   string
+	nextCursor string
+	started    bool
+}
+
+func newAttachmentWriter(w http.ResponseWriter, filename string) *attachmentWriter {
+	return &attachmentWriter{w: w, filename: filename}
+}
+
+// clearWriteDeadline - lift write timeout of server for streamed file or import of several files
+// (large table takes longer than request timeout)
+func clearWriteDeadline(w http.ResponseWriter, log *slog.Logger) {
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn("failed to clear write deadline", slog.Any("err", err))
+	}
+}
+
+func (a *attachmentWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+
+		contentType := mimeCSV
+		switch {
+		case strings.HasSuffix(a.filename, domain.ExtXLSX):
+			contentType = mimeXLSX
+		case strings.HasSuffix(a.filename, extSQLite):
+			contentType = mimeSQLite
+		}
+		a.w.Header().Set("Content-Type", contentType)
+		a.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.filename}))
+		if a.nextCursor != "" {
+			a.w.Header().Set("X-Next-Cursor", a.nextCursor)
+		}
+		a.w.WriteHeader(http.StatusOK)
+	}
+	return a.w.Write(p)
+}
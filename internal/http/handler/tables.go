@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// ListTables godoc
+// @Summary List tables
+// @Description Returns tables created by the service with estimated row count and size, sorted by schema and name.
+// @Tags tables
+// @Produce json
+// @Param schema query string false "Schema of tables (default all schemas)"
+// @Param name query string false "Substring of table name (case-insensitive)"
+// @Param limit query int false "Max number of tables (default 50, max 1000)"
+// @Param offset query int false "Number of tables to skip"
+// @Success 200 {object} TableListResponse
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables [get]
+func (h *Handler) ListTables(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.ListTables"
+	log := h.log.With(slog.String("op", op))
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := models.TableFilter{
+		Schema: r.URL.Query().Get("schema"),
+		Name:   r.URL.Query().Get("name"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	tables, err := h.service.Tables().List(r.Context(), filter)
+	if err != nil {
+		log.Error("failed to list tables", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, newTableListResponse(tables, limit, offset))
+}
+
+// DescribeTable godoc
+// @Summary Describe table
+// @Description Returns columns with DB types, exact row count and size of a table created by the service.
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Success 200 {object} TableDetailResponse
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables/{name} [get]
+func (h *Handler) DescribeTable(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.DescribeTable"
+	log := h.log.With(slog.String("op", op))
+
+	info, err := h.service.Tables().Describe(r.Context(), r.PathValue("name"))
+	if err != nil {
+		log.Error("failed to describe table", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, TableDetailResponse{
+		Response:      Response{Status: "OK"},
+		TableResponse: newTableResponse(info),
+	})
+}
+
+// RenameTable godoc
+// @Summary Rename table
+// @Description Renames a table created by the service together with its rejects table and profile.
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Param request body RenameTableRequest true "New table name (lowercase letters, digits and underscores)"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables/{name} [patch]
+func (h *Handler) RenameTable(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.RenameTable"
+	log := h.log.With(slog.String("op", op))
+
+	var req RenameTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.service.Tables().Rename(r.Context(), r.PathValue("name"), req.Name); err != nil {
+		log.Error("failed to rename table", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, Response{Status: "OK"})
+}
+
+// DropTable godoc
+// @Summary Drop table
+// @Description Drops a table created by the service together with its rejects table and profile.
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name, schema.table for non-default schema"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /tables/{name} [delete]
+func (h *Handler) DropTable(w http.ResponseWriter, r *http.Request) {
+	const op = "delivery.http.DropTable"
+	log := h.log.With(slog.String("op", op))
+
+	if err := h.service.Tables().Drop(r.Context(), r.PathValue("name")); err != nil {
+		log.Error("failed to drop table", slog.Any("err", err))
+
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, Response{Status: "OK"})
+}
@@ -0,0 +1,85 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// ClickHouse - ClickHouse: schema is database, Nullable columns, MergeTree engine, no transactions
+var ClickHouse Dialect = &sqlDialect{
+	name: models.DialectClickHouse,
+	types: map[models.DataType]string{
+		models.DataTypeInteger: "Int64",
+		models.DataTypeFloat:   "Float64",
+		models.DataTypeBoolean: "Bool",
+		models.DataTypeString:  "String",
+		models.DataTypeUUID:    "UUID",
+	},
+	fallbackType: "String",
+	quote:        quoteWith("`", "`"),
+	schemas:      true,
+	placeholder:  func(int) string { return "?" },
+	literal:      literalOf(clickhouseString, "true", "false"),
+	create:       clickhouseCreate,
+	bulk:         BulkLoad{BatchSize: 1000},
+}
+
+// clickhouseString - string literal, quote and backslash are escaped by backslash
+func clickhouseString(s string) string {
+	return `'` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + `'`
+}
+
+func clickhouseCreate(d *sqlDialect, table models.Table, extra []Column) []string {
+	var stmts []string
+
+	if table.Schema != "" {
+		stmts = append(stmts, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", d.quote(table.Schema)))
+	}
+
+	quotedTableName := d.QualifiedName(table.Schema, table.Name)
+	stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedTableName))
+
+	var constraints []string
+	definition := func(col models.Column) string {
+		// columns are NOT NULL by default, empty typed values are NULL
+		def := fmt.Sprintf("Nullable(%s)", d.ColumnType(col.Type))
+		if len(col.EnumValues) > 0 {
+			switch table.EnumMode {
+			case models.EnumModeType:
+				values := make([]string, len(col.EnumValues))
+				for i, v := range col.EnumValues {
+					values[i] = fmt.Sprintf("%s = %d", clickhouseString(v), i+1)
+				}
+				def = fmt.Sprintf("Nullable(Enum16(%s))", strings.Join(values, ", "))
+			case models.EnumModeCheck:
+				def = "LowCardinality(Nullable(String))"
+				constraints = append(constraints, fmt.Sprintf("CONSTRAINT %s CHECK isNull(%s) OR %s IN (%s)",
+					d.quote(col.Name+"_enum"), d.quote(col.Name), d.quote(col.Name), clickhouseStrings(col.EnumValues)))
+			}
+		}
+		if col.Source != "" && col.Source != col.Name {
+			def += " COMMENT " + clickhouseString(col.Source)
+		}
+		return def
+	}
+
+	defs := d.columnDefinitions(table, extra, definition)
+	for _, c := range constraints {
+		defs += ", " + c
+	}
+	stmts = append(stmts, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = MergeTree ORDER BY tuple();",
+		quotedTableName, defs))
+
+	return stmts
+}
+
+// clickhouseStrings - return comma separated string literals
+func clickhouseStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = clickhouseString(v)
+	}
+	return strings.Join(quoted, ", ")
+}
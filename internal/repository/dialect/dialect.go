@@ -0,0 +1,237 @@
+// Package dialect - SQL syntax of supported databases: type mapping, quoting,
+// placeholders, literals, DDL and bulk-load strategy
+package dialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// Dialect - SQL syntax of target database
+type Dialect interface {
+	// Name - name of dialect
+	Name() models.SQLDialect
+	// ColumnType - return SQL type of column data type
+	ColumnType(t models.DataType) string
+	// QuoteIdentifier - return quoted table or column name
+	QuoteIdentifier(name string) string
+	// QualifiedName - return quoted schema.name (name for empty schema)
+	QualifiedName(schema, name string) string
+	// Placeholder - return placeholder of i-th (from 1) argument of prepared statement
+	Placeholder(i int) string
+	// Literal - return SQL literal of query argument of column type (nil --> NULL)
+	Literal(arg any, t models.DataType) string
+	// CreateTable - return statements (with ;) which (re)create table, extra columns are added after data columns
+	CreateTable(table models.Table, extra ...Column) []string
+	// InsertQuery - return prepared INSERT of one row (data columns, then extra columns)
+	InsertQuery(table models.Table, extra ...Column) string
+	// BulkLoad - return how rows are loaded by script
+	BulkLoad() BulkLoad
+}
+
+// Column - column added to table by repository (e.g. lineage), type is SQL type of dialect
+type Column struct {
+	Name string
+	Type string
+}
+
+// BulkLoad - represent loading of rows by script: transaction and rows in one multi-row INSERT
+type BulkLoad struct {
+	// Begin, Commit - statements of transaction ("" - dialect has no transactions)
+	Begin  string
+	Commit string
+	// BatchSize - max rows in one INSERT statement
+	BatchSize int
+}
+
+// sqlDialect - Dialect described by its differences
+type sqlDialect struct {
+	name  models.SQLDialect
+	types map[models.DataType]string
+	// fallbackType - type of unknown data types
+	fallbackType string
+	quote        func(name string) string
+	// schemas - dialect has schemas (otherwise schema is ignored)
+	schemas     bool
+	placeholder func(i int) string
+	literal     func(arg any, t models.DataType) string
+	create      func(d *sqlDialect, table models.Table, extra []Column) []string
+	bulk        BulkLoad
+}
+
+// ByName - return dialect by its name ("" - postgres)
+func ByName(name models.SQLDialect) (Dialect, error) {
+	const op = "dialect.ByName"
+
+	switch name {
+	case "", models.DialectPostgres:
+		return Postgres, nil
+	case models.DialectMySQL:
+		return MySQL, nil
+	case models.DialectSQLite:
+		return SQLite, nil
+	case models.DialectClickHouse:
+		return ClickHouse, nil
+	case models.DialectMSSQL:
+		return MSSQL, nil
+	default:
+		return nil, fmt.Errorf("%s: %q: %w", op, name, domain.ErrUnsupportedDialect)
+	}
+}
+
+func (d *sqlDialect) Name() models.SQLDialect {
+	return d.name
+}
+
+func (d *sqlDialect) ColumnType(t models.DataType) string {
+	if typ, ok := d.types[t]; ok {
+		return typ
+	}
+	return d.fallbackType
+}
+
+func (d *sqlDialect) QuoteIdentifier(name string) string {
+	return d.quote(name)
+}
+
+func (d *sqlDialect) QualifiedName(schema, name string) string {
+	if schema == "" || !d.schemas {
+		return d.quote(name)
+	}
+	return d.quote(schema) + "." + d.quote(name)
+}
+
+func (d *sqlDialect) Placeholder(i int) string {
+	return d.placeholder(i)
+}
+
+func (d *sqlDialect) Literal(arg any, t models.DataType) string {
+	return d.literal(arg, t)
+}
+
+func (d *sqlDialect) CreateTable(table models.Table, extra ...Column) []string {
+	return d.create(d, table, extra)
+}
+
+func (d *sqlDialect) InsertQuery(table models.Table, extra ...Column) string {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(d.QualifiedName(table.Schema, table.Name))
+	sb.WriteString(" (")
+	sb.WriteString(d.columnList(table, extra))
+	sb.WriteString(") VALUES (")
+
+	for i := 0; i < len(table.Columns)+len(extra); i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(d.placeholder(i + 1))
+	}
+	sb.WriteString(");")
+	return sb.String()
+}
+
+func (d *sqlDialect) BulkLoad() BulkLoad {
+	return d.bulk
+}
+
+// columnList - return quoted names of data and extra columns
+func (d *sqlDialect) columnList(table models.Table, extra []Column) string {
+	quoted := make([]string, 0, len(table.Columns)+len(extra))
+	for _, col := range table.Columns {
+		quoted = append(quoted, d.quote(col.Name))
+	}
+	for _, col := range extra {
+		quoted = append(quoted, d.quote(col.Name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// columnDefinitions - return "name type" of data columns (definition returns type) and extra columns
+func (d *sqlDialect) columnDefinitions(table models.Table, extra []Column, definition func(col models.Column) string) string {
+	defs := make([]string, 0, len(table.Columns)+len(extra))
+	for _, col := range table.Columns {
+		defs = append(defs, d.quote(col.Name)+" "+definition(col))
+	}
+	for _, col := range extra {
+		defs = append(defs, d.quote(col.Name)+" "+col.Type)
+	}
+	return strings.Join(defs, ", ")
+}
+
+// enumCheck - return CHECK restriction of enum column
+func (d *sqlDialect) enumCheck(col models.Column) string {
+	values := make([]string, len(col.EnumValues))
+	for i, v := range col.EnumValues {
+		values[i] = d.literal(v, models.DataTypeString)
+	}
+	return fmt.Sprintf("CHECK (%s IN (%s))", d.quote(col.Name), strings.Join(values, ", "))
+}
+
+// Arg - convert value of column j to query argument (empty typed value --> NULL)
+func Arg(table models.Table, j int, v string) any {
+	if j >= len(table.Columns) {
+		return v
+	}
+	col := table.Columns[j]
+	if col.Type == models.DataTypeString && len(col.EnumValues) == 0 {
+		return v
+	}
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	if len(col.EnumValues) > 0 {
+		return strings.TrimSpace(v)
+	}
+	return v
+}
+
+// quoteWith - return name in quotes, closing quote in name is doubled
+func quoteWith(open, close string) func(name string) string {
+	return func(name string) string {
+		return open + strings.ReplaceAll(name, close, close+close) + close
+	}
+}
+
+// quoteString - return string literal, quote is doubled
+func quoteString(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// typedLiteral - return literal of number or boolean column as bare value (ok false - quote as string)
+func typedLiteral(s string, t models.DataType, trueValue, falseValue string) (string, bool) {
+	v := strings.TrimSpace(s)
+	switch t {
+	case models.DataTypeInteger, models.DataTypeFloat:
+		// ParseFloat also accepts Inf, NaN and hex, they are not SQL numbers
+		if _, err := strconv.ParseFloat(v, 64); err == nil && strings.Trim(v, "+-.0123456789eE") == "" {
+			return v, true
+		}
+	case models.DataTypeBoolean:
+		if b, err := strconv.ParseBool(v); err == nil {
+			if b {
+				return trueValue, true
+			}
+			return falseValue, true
+		}
+	}
+	return "", false
+}
+
+// literalOf - return literal function: NULL, bare numbers and booleans, other values quoted by quote
+func literalOf(quote func(s string) string, trueValue, falseValue string) func(arg any, t models.DataType) string {
+	return func(arg any, t models.DataType) string {
+		if arg == nil {
+			return "NULL"
+		}
+		s := fmt.Sprint(arg)
+		if v, ok := typedLiteral(s, t, trueValue, falseValue); ok {
+			return v
+		}
+		return quote(s)
+	}
+}
@@ -0,0 +1,145 @@
+package dialect_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+)
+
+func TestDialect_CreateTable(t *testing.T) {
+	table := models.Table{
+		Name:     "clients",
+		Schema:   "crm",
+		EnumMode: models.EnumModeCheck,
+		Columns: []models.Column{
+			{Name: "id", Source: "id", Type: models.DataTypeInteger},
+			{Name: "imya", Source: "Имя", Type: models.DataTypeString},
+			{Name: "status", Source: "status", Type: models.DataTypeString, EnumValues: []string{"new", "vip"}},
+		},
+	}
+
+	tests := []struct {
+		dialect models.SQLDialect
+		want    []string
+	}{
+		{
+			dialect: models.DialectMySQL,
+			want: []string{
+				"CREATE DATABASE IF NOT EXISTS `crm`;",
+				"DROP TABLE IF EXISTS `crm`.`clients`;",
+				"CREATE TABLE IF NOT EXISTS `crm`.`clients` (`id` BIGINT, `imya` LONGTEXT COMMENT 'Имя', " +
+					"`status` LONGTEXT CHECK (`status` IN ('new', 'vip'))) DEFAULT CHARSET = utf8mb4;",
+			},
+		},
+		{
+			dialect: models.DialectSQLite,
+			want: []string{
+				`DROP TABLE IF EXISTS "clients";`,
+				`CREATE TABLE IF NOT EXISTS "clients" ("id" INTEGER, "imya" TEXT, "status" TEXT CHECK ("status" IN ('new', 'vip'))) STRICT;`,
+			},
+		},
+		{
+			dialect: models.DialectClickHouse,
+			want: []string{
+				"CREATE DATABASE IF NOT EXISTS `crm`;",
+				"DROP TABLE IF EXISTS `crm`.`clients`;",
+				"CREATE TABLE IF NOT EXISTS `crm`.`clients` (`id` Nullable(Int64), `imya` Nullable(String) COMMENT 'Имя', " +
+					"`status` LowCardinality(Nullable(String)), " +
+					"CONSTRAINT `status_enum` CHECK isNull(`status`) OR `status` IN ('new', 'vip')) ENGINE = MergeTree ORDER BY tuple();",
+			},
+		},
+		{
+			dialect: models.DialectMSSQL,
+			want: []string{
+				"IF SCHEMA_ID(N'crm') IS NULL EXEC(N'CREATE SCHEMA [crm]');",
+				"DROP TABLE IF EXISTS [crm].[clients];",
+				"CREATE TABLE [crm].[clients] ([id] BIGINT, [imya] NVARCHAR(MAX), [status] NVARCHAR(MAX) CHECK ([status] IN (N'new', N'vip')));",
+				"EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'Имя', @level0type = N'SCHEMA', @level0name = N'crm', " +
+					"@level1type = N'TABLE', @level1name = N'clients', @level2type = N'COLUMN', @level2name = N'imya';",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			d, err := dialect.ByName(tt.dialect)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.CreateTable(table))
+		})
+	}
+}
+
+func TestDialect_Literal(t *testing.T) {
+	tests := []struct {
+		dialect models.SQLDialect
+		arg     any
+		typ     models.DataType
+		want    string
+	}{
+		{models.DialectPostgres, "42", models.DataTypeInteger, `'42'`},
+		{models.DialectPostgres, nil, models.DataTypeInteger, `NULL`},
+		{models.DialectMySQL, "42", models.DataTypeInteger, `42`},
+		{models.DialectMySQL, "Inf", models.DataTypeFloat, `'Inf'`},
+		{models.DialectMySQL, `O'Brien \ Co`, models.DataTypeString, `'O''Brien \\ Co'`},
+		{models.DialectMySQL, "true", models.DataTypeBoolean, `TRUE`},
+		{models.DialectSQLite, "false", models.DataTypeBoolean, `0`},
+		{models.DialectClickHouse, `O'Brien`, models.DataTypeString, `'O\'Brien'`},
+		{models.DialectMSSQL, "Имя", models.DataTypeString, `N'Имя'`},
+		{models.DialectMSSQL, "true", models.DataTypeBoolean, `1`},
+	}
+
+	for _, tt := range tests {
+		d, err := dialect.ByName(tt.dialect)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, d.Literal(tt.arg, tt.typ), "%s %v", tt.dialect, tt.arg)
+	}
+}
+
+func TestDialect_InsertQuery(t *testing.T) {
+	table := models.Table{
+		Name:    "orders",
+		Columns: []models.Column{{Name: "id", Type: models.DataTypeInteger}},
+	}
+	extra := dialect.Column{Name: "_import_id", Type: "BIGINT"}
+
+	assert.Equal(t, `INSERT INTO "orders" ("id", "_import_id") VALUES ($1, $2);`, dialect.Postgres.InsertQuery(table, extra))
+	assert.Equal(t, "INSERT INTO `orders` (`id`) VALUES (?);", dialect.MySQL.InsertQuery(table))
+	assert.Equal(t, `INSERT INTO [orders] ([id]) VALUES (@p1);`, dialect.MSSQL.InsertQuery(table))
+
+	_, err := dialect.ByName("oracle")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedDialect)
+}
+
+func TestDialect_EnumTypeName(t *testing.T) {
+	table := models.Table{Name: "orders", EnumMode: models.EnumModeType}
+	col := models.Column{Name: "status", Type: models.DataTypeString, EnumValues: []string{"new"}}
+
+	assert.Equal(t, "orders_status_enum", dialect.EnumTypeName(table, col))
+
+	// long names are cut within identifier limit, cut names of different columns differ
+	table.Name = strings.Repeat("статус_", 5)
+	first := dialect.EnumTypeName(table, models.Column{Name: "payment_status_of_order"})
+	second := dialect.EnumTypeName(table, models.Column{Name: "payment_status_of_delivery"})
+
+	assert.LessOrEqual(t, len(first), 63)
+	assert.True(t, utf8.ValidString(first))
+	assert.True(t, strings.HasSuffix(first, "_enum"))
+	assert.NotEqual(t, first, second)
+
+	// type of replaced table is dropped without CASCADE
+	table.Name = "orders"
+	table.Columns = []models.Column{col}
+	assert.Equal(t, []string{
+		`DROP TABLE IF EXISTS "orders" CASCADE;`,
+		`DROP TYPE IF EXISTS "orders_status_enum";`,
+		`CREATE TYPE "orders_status_enum" AS ENUM ('new');`,
+		`CREATE TABLE IF NOT EXISTS "orders" ("status" "orders_status_enum");`,
+	}, dialect.Postgres.CreateTable(table))
+}
@@ -0,0 +1,73 @@
+package dialect
+
+import (
+	"fmt"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// MSSQL - Microsoft SQL Server 2016+: unicode N” literals, CHECK for enums,
+// column descriptions as extended properties, at most 1000 rows in one VALUES
+var MSSQL Dialect = &sqlDialect{
+	name: models.DialectMSSQL,
+	types: map[models.DataType]string{
+		models.DataTypeInteger:  "BIGINT",
+		models.DataTypeFloat:    "FLOAT",
+		models.DataTypeBoolean:  "BIT",
+		models.DataTypeString:   "NVARCHAR(MAX)",
+		models.DataTypeUUID:     "UNIQUEIDENTIFIER",
+		models.DataTypeInet:     "NVARCHAR(45)",
+		models.DataTypeCIDR:     "NVARCHAR(49)",
+		models.DataTypeInterval: "NVARCHAR(255)",
+	},
+	fallbackType: "NVARCHAR(MAX)",
+	quote:        quoteWith("[", "]"),
+	schemas:      true,
+	placeholder:  func(i int) string { return fmt.Sprintf("@p%d", i) },
+	literal:      literalOf(mssqlString, "1", "0"),
+	create:       mssqlCreate,
+	bulk:         BulkLoad{Begin: "BEGIN TRANSACTION;", Commit: "COMMIT;", BatchSize: 1000},
+}
+
+// mssqlString - unicode string literal
+func mssqlString(s string) string {
+	return "N" + quoteString(s)
+}
+
+func mssqlCreate(d *sqlDialect, table models.Table, extra []Column) []string {
+	var stmts []string
+
+	// CREATE SCHEMA must be the only statement of batch
+	if table.Schema != "" {
+		stmts = append(stmts, fmt.Sprintf("IF SCHEMA_ID(%s) IS NULL EXEC(%s);",
+			mssqlString(table.Schema), mssqlString("CREATE SCHEMA "+d.quote(table.Schema))))
+	}
+
+	quotedTableName := d.QualifiedName(table.Schema, table.Name)
+	stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedTableName))
+
+	definition := func(col models.Column) string {
+		if len(col.EnumValues) > 0 && table.EnumMode != models.EnumModeNone {
+			return d.ColumnType(col.Type) + " " + d.enumCheck(col)
+		}
+		return d.ColumnType(col.Type)
+	}
+	stmts = append(stmts, fmt.Sprintf("CREATE TABLE %s (%s);", quotedTableName, d.columnDefinitions(table, extra, definition)))
+
+	// Original headers
+	schema := table.Schema
+	if schema == "" {
+		schema = "dbo"
+	}
+	for _, col := range table.Columns {
+		if col.Source == "" || col.Source == col.Name {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("EXEC sp_addextendedproperty @name = N'MS_Description', @value = %s, "+
+			"@level0type = N'SCHEMA', @level0name = %s, @level1type = N'TABLE', @level1name = %s, "+
+			"@level2type = N'COLUMN', @level2name = %s;",
+			mssqlString(col.Source), mssqlString(schema), mssqlString(table.Name), mssqlString(col.Name)))
+	}
+
+	return stmts
+}
@@ -0,0 +1,72 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// MySQL - MySQL 8: schema is database, ENUM or CHECK, inline column comments
+var MySQL Dialect = &sqlDialect{
+	name: models.DialectMySQL,
+	types: map[models.DataType]string{
+		models.DataTypeInteger: "BIGINT",
+		// NUMERIC of MySQL is DECIMAL(10,0) and drops fraction
+		models.DataTypeFloat:    "DOUBLE",
+		models.DataTypeBoolean:  "BOOLEAN",
+		models.DataTypeString:   "LONGTEXT",
+		models.DataTypeUUID:     "CHAR(36)",
+		models.DataTypeJSON:     "JSON",
+		models.DataTypeInet:     "VARCHAR(45)",
+		models.DataTypeCIDR:     "VARCHAR(49)",
+		models.DataTypeInterval: "VARCHAR(255)",
+	},
+	fallbackType: "LONGTEXT",
+	quote:        quoteWith("`", "`"),
+	schemas:      true,
+	placeholder:  func(int) string { return "?" },
+	literal:      literalOf(mysqlString, "TRUE", "FALSE"),
+	create:       mysqlCreate,
+	bulk:         BulkLoad{Begin: "START TRANSACTION;", Commit: "COMMIT;", BatchSize: 1000},
+}
+
+// mysqlString - string literal, backslash is escape character in MySQL
+func mysqlString(s string) string {
+	return quoteString(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+func mysqlCreate(d *sqlDialect, table models.Table, extra []Column) []string {
+	var stmts []string
+
+	if table.Schema != "" {
+		stmts = append(stmts, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", d.quote(table.Schema)))
+	}
+
+	quotedTableName := d.QualifiedName(table.Schema, table.Name)
+	stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedTableName))
+
+	definition := func(col models.Column) string {
+		def := d.ColumnType(col.Type)
+		if len(col.EnumValues) > 0 {
+			switch table.EnumMode {
+			case models.EnumModeType:
+				values := make([]string, len(col.EnumValues))
+				for i, v := range col.EnumValues {
+					values[i] = mysqlString(v)
+				}
+				def = fmt.Sprintf("ENUM(%s)", strings.Join(values, ", "))
+			case models.EnumModeCheck:
+				def += " " + d.enumCheck(col)
+			}
+		}
+		if col.Source != "" && col.Source != col.Name {
+			def += " COMMENT " + mysqlString(col.Source)
+		}
+		return def
+	}
+	stmts = append(stmts, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) DEFAULT CHARSET = utf8mb4;",
+		quotedTableName, d.columnDefinitions(table, extra, definition)))
+
+	return stmts
+}
@@ -0,0 +1,125 @@
+package dialect
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// Postgres - PostgreSQL: enum types or CHECK, COMMENT ON COLUMN, typed columns cast quoted text
+var Postgres Dialect = &sqlDialect{
+	name: models.DialectPostgres,
+	types: map[models.DataType]string{
+		models.DataTypeInteger:  "BIGINT",
+		models.DataTypeFloat:    "NUMERIC",
+		models.DataTypeBoolean:  "BOOLEAN",
+		models.DataTypeString:   "TEXT",
+		models.DataTypeUUID:     "UUID",
+		models.DataTypeJSON:     "JSONB",
+		models.DataTypeInet:     "INET",
+		models.DataTypeCIDR:     "CIDR",
+		models.DataTypeInterval: "INTERVAL",
+	},
+	fallbackType: "TEXT",
+	quote:        quoteWith(`"`, `"`),
+	schemas:      true,
+	placeholder:  func(i int) string { return fmt.Sprintf("$%d", i) },
+	literal:      postgresLiteral,
+	create:       postgresCreate,
+	bulk:         BulkLoad{Begin: "BEGIN;", Commit: "COMMIT;", BatchSize: 1000},
+}
+
+// postgresLiteral - every value is quoted, Postgres casts it to column type
+func postgresLiteral(arg any, _ models.DataType) string {
+	if arg == nil {
+		return "NULL"
+	}
+	return quoteString(fmt.Sprint(arg))
+}
+
+func postgresCreate(d *sqlDialect, table models.Table, extra []Column) []string {
+	var stmts []string
+
+	// Schema
+	if table.Schema != "" {
+		stmts = append(stmts, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", d.quote(table.Schema)))
+	}
+
+	// Delete table
+	quotedTableName := d.QualifiedName(table.Schema, table.Name)
+	stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", quotedTableName))
+
+	// Enum types (no CASCADE: type used by other table is not dropped, statement fails instead)
+	if table.EnumMode == models.EnumModeType {
+		for _, col := range table.Columns {
+			if len(col.EnumValues) == 0 {
+				continue
+			}
+			quotedTypeName := d.QualifiedName(table.Schema, EnumTypeName(table, col))
+			stmts = append(stmts,
+				fmt.Sprintf("DROP TYPE IF EXISTS %s;", quotedTypeName),
+				fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", quotedTypeName, quoteStrings(col.EnumValues)))
+		}
+	}
+
+	definition := func(col models.Column) string {
+		if len(col.EnumValues) == 0 {
+			return d.ColumnType(col.Type)
+		}
+		switch table.EnumMode {
+		case models.EnumModeType:
+			return d.QualifiedName(table.Schema, EnumTypeName(table, col))
+		case models.EnumModeCheck:
+			return d.ColumnType(col.Type) + " " + d.enumCheck(col)
+		default:
+			return d.ColumnType(col.Type)
+		}
+	}
+	stmts = append(stmts, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s);",
+		quotedTableName, d.columnDefinitions(table, extra, definition)))
+
+	// Original headers
+	for _, col := range table.Columns {
+		if col.Source == "" || col.Source == col.Name {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;",
+			quotedTableName, d.quote(col.Name), quoteString(col.Source)))
+	}
+
+	return stmts
+}
+
+// maxIdentifierLen - Postgres identifier limit in bytes (NAMEDATALEN - 1), longer names are cut silently
+const maxIdentifierLen = 63
+
+// EnumTypeName - return name of Postgres enum type for column, long name is cut
+// and gets hash of full name (cut names of different columns must not match)
+func EnumTypeName(table models.Table, col models.Column) string {
+	name := table.Name + "_" + col.Name + "_enum"
+	if len(name) <= maxIdentifierLen {
+		return name
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	suffix := fmt.Sprintf("_%08x_enum", h.Sum32())
+
+	prefix := name[:maxIdentifierLen-len(suffix)]
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix + suffix
+}
+
+// quoteStrings - return comma separated string literals
+func quoteStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteString(v)
+	}
+	return strings.Join(quoted, ", ")
+}
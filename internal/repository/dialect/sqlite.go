@@ -0,0 +1,42 @@
+package dialect
+
+import (
+	"fmt"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// SQLite - SQLite 3.37+: no schemas (file is one database), STRICT tables (values are type checked),
+// CHECK for enums, booleans are 0/1
+var SQLite Dialect = &sqlDialect{
+	name: models.DialectSQLite,
+	types: map[models.DataType]string{
+		models.DataTypeInteger: "INTEGER",
+		models.DataTypeFloat:   "REAL",
+		models.DataTypeBoolean: "INTEGER",
+		models.DataTypeString:  "TEXT",
+	},
+	fallbackType: "TEXT",
+	quote:        quoteWith(`"`, `"`),
+	placeholder:  func(int) string { return "?" },
+	literal:      literalOf(quoteString, "1", "0"),
+	create:       sqliteCreate,
+	// SQLITE_MAX_COMPOUND_SELECT limits multi-row VALUES to 500 rows
+	bulk: BulkLoad{Begin: "BEGIN;", Commit: "COMMIT;", BatchSize: 500},
+}
+
+func sqliteCreate(d *sqlDialect, table models.Table, extra []Column) []string {
+	quotedTableName := d.QualifiedName(table.Schema, table.Name)
+
+	definition := func(col models.Column) string {
+		if len(col.EnumValues) > 0 && table.EnumMode != models.EnumModeNone {
+			return d.ColumnType(col.Type) + " " + d.enumCheck(col)
+		}
+		return d.ColumnType(col.Type)
+	}
+
+	return []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedTableName),
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) STRICT;", quotedTableName, d.columnDefinitions(table, extra, definition)),
+	}
+}
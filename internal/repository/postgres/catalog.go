@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// schemaOrCurrent - SQL expression of schema in $1, empty schema is current schema of search_path
+const schemaOrCurrent = "COALESCE(NULLIF($1, ''), current_schema())"
+
+// Register - mark table ("schema.name" or "name") as created by service in managed_tables
+func (r *tableRepository) Register(ctx context.Context, name string, importID int64) error {
+	const op = "postgres.table.Register"
+
+	schema, table := models.SplitQualifiedName(name)
+
+	query := `INSERT INTO managed_tables (schema_name, name, last_import_id) VALUES (` + schemaOrCurrent + `, $2, $3)
+ON CONFLICT (schema_name, name) DO UPDATE SET last_import_id = EXCLUDED.last_import_id, updated_at = now();`
+
+	// 0 - import was not recorded
+	lastImportID := sql.NullInt64{Int64: importID, Valid: importID != 0}
+
+	if _, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query, schema, table, lastImportID); err != nil {
+		return fmt.Errorf("%s: failed to register table %s: %w", op, name, err)
+	}
+
+	return nil
+}
+
+// List - list tables from managed_tables with estimated rows and size
+func (r *tableRepository) List(ctx context.Context, filter models.TableFilter) ([]models.TableInfo, error) {
+	const op = "postgres.table.List"
+
+	query := `SELECT m.schema_name, m.name, m.created_at, m.updated_at,
+GREATEST(COALESCE(c.reltuples, 0), 0)::BIGINT, COALESCE(pg_total_relation_size(c.oid), 0)
+FROM managed_tables m
+LEFT JOIN pg_class c ON c.oid = to_regclass(format('%I.%I', m.schema_name, m.name))
+WHERE ($1 = '' OR m.schema_name = $1) AND m.name ILIKE $2
+ORDER BY m.schema_name, m.name LIMIT $3 OFFSET $4;`
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, query, filter.Schema, likePattern(filter.Name), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list tables: %w", op, err)
+	}
+	defer rows.Close()
+
+	tables := make([]models.TableInfo, 0, filter.Limit)
+	for rows.Next() {
+		var t models.TableInfo
+		if err := rows.Scan(&t.Schema, &t.Name, &t.CreatedAt, &t.UpdatedAt, &t.Rows, &t.SizeBytes); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan table: %w", op, err)
+		}
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read tables: %w", op, err)
+	}
+
+	return tables, nil
+}
+
+// Describe - get columns, exact row count and size of table
+func (r *tableRepository) Describe(ctx context.Context, name string) (models.TableInfo, error) {
+	const op = "postgres.table.Describe"
+
+	schema, table := models.SplitQualifiedName(name)
+	info := models.TableInfo{Name: table}
+
+	query := `SELECT schema_name, created_at, updated_at FROM managed_tables
+WHERE schema_name = ` + schemaOrCurrent + ` AND name = $2;`
+
+	err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, schema, table).Scan(&info.Schema, &info.CreatedAt, &info.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TableInfo{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: failed to get table %s: %w", op, name, err)
+	}
+
+	info.Columns, err = r.columns(ctx, info.Schema, table)
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// registered, but dropped outside of service
+	if len(info.Columns) == 0 {
+		return models.TableInfo{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	quotedTableName := qualifiedName(info.Schema, table)
+
+	countQuery := fmt.Sprintf("SELECT count(*) FROM %s;", quotedTableName)
+	if err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, countQuery).Scan(&info.Rows); err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: failed to count rows of %s: %w", op, name, err)
+	}
+
+	sizeQuery := `SELECT pg_total_relation_size(to_regclass($1));`
+	if err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, sizeQuery, quotedTableName).Scan(&info.SizeBytes); err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: failed to get size of %s: %w", op, name, err)
+	}
+
+	return info, nil
+}
+
+// Rename - rename table with its rejects table, catalog record and profile (table stays in its schema)
+func (r *tableRepository) Rename(ctx context.Context, name, newName string) error {
+	const op = "postgres.table.Rename"
+	log := r.log.With("op", op)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	schema, table, err := lockManagedTable(ctx, tx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;ALTER TABLE IF EXISTS %s RENAME TO %s;",
+		qualifiedName(schema, table), quoteIdentifier(newName),
+		qualifiedName(schema, rejectsTableName(table)), quoteIdentifier(rejectsTableName(newName)))
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "42P07" { // duplicate_table
+			return fmt.Errorf("%s: %s: %w", op, newName, domain.ErrTableExists)
+		}
+		return fmt.Errorf("%s: failed to rename table %s: %w", op, name, err)
+	}
+
+	catalogQuery := `UPDATE managed_tables SET name = $3, updated_at = now() WHERE schema_name = $1 AND name = $2;`
+	if _, err := tx.ExecContext(ctx, catalogQuery, schema, table, newName); err != nil {
+		return fmt.Errorf("%s: failed to update catalog: %w", op, err)
+	}
+
+	// profile key keeps the form it was saved with on upload
+	requestedSchema, _ := models.SplitQualifiedName(name)
+	newProfileName := models.Table{Schema: requestedSchema, Name: newName}.QualifiedName()
+
+	profileQuery := `UPDATE table_profiles SET table_name = $2 WHERE table_name = $1;`
+	if _, err := tx.ExecContext(ctx, profileQuery, name, newProfileName); err != nil {
+		return fmt.Errorf("%s: failed to update profile: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// Drop - drop table with its rejects table, catalog record and profile
+func (r *tableRepository) Drop(ctx context.Context, name string) error {
+	const op = "postgres.table.Drop"
+	log := r.log.With("op", op)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	schema, table, err := lockManagedTable(ctx, tx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;DROP TABLE IF EXISTS %s;",
+		qualifiedName(schema, table), qualifiedName(schema, rejectsTableName(table)))
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: failed to drop table %s: %w", op, name, err)
+	}
+
+	catalogQuery := `DELETE FROM managed_tables WHERE schema_name = $1 AND name = $2;`
+	if _, err := tx.ExecContext(ctx, catalogQuery, schema, table); err != nil {
+		return fmt.Errorf("%s: failed to update catalog: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM table_profiles WHERE table_name = $1;`, name); err != nil {
+		return fmt.Errorf("%s: failed to delete profile: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// columns - get columns of table with DB types ("" schema - current schema)
+func (r *tableRepository) columns(ctx context.Context, schema, name string) ([]models.ColumnInfo, error) {
+	query := `SELECT column_name, CASE WHEN data_type = 'USER-DEFINED' THEN udt_name ELSE data_type END
+FROM information_schema.columns
+WHERE table_schema = ` + schemaOrCurrent + ` AND table_name = $2
+ORDER BY ordinal_position;`
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, query, schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnInfo
+	for rows.Next() {
+		var col models.ColumnInfo
+		if err := rows.Scan(&col.Name, &col.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	return columns, nil
+}
+
+// managedTable - return resolved schema and name of table created by service,
+// ErrTableNotFound if table is not created by service
+func (r *tableRepository) managedTable(ctx context.Context, name string) (string, string, error) {
+	schema, table := models.SplitQualifiedName(name)
+
+	query := `SELECT schema_name FROM managed_tables WHERE schema_name = ` + schemaOrCurrent + ` AND name = $2;`
+
+	err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, schema, table).Scan(&schema)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("%s: %w", name, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get table %s: %w", name, err)
+	}
+
+	return schema, table, nil
+}
+
+// lockManagedTable - lock catalog record of table and return its resolved schema and name,
+// ErrTableNotFound if table is not created by service
+func lockManagedTable(ctx context.Context, tx *sqlstore.Tx, name string) (string, string, error) {
+	schema, table := models.SplitQualifiedName(name)
+
+	query := `SELECT schema_name FROM managed_tables WHERE schema_name = ` + schemaOrCurrent + ` AND name = $2 FOR UPDATE;`
+
+	err := tx.QueryRowContext(ctx, query, schema, table).Scan(&schema)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("%s: %w", name, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to lock catalog record of %s: %w", name, err)
+	}
+
+	return schema, table, nil
+}
+
+// likePattern - return ILIKE pattern for substring search
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
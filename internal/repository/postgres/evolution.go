@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// Types of widening path INTEGER --> BIGINT --> NUMERIC --> TEXT
+const (
+	widenBigint  = "bigint"
+	widenNumeric = "numeric"
+	widenText    = "text"
+)
+
+// PlanChanges - compare analyzed table with existing table from information_schema
+func (r *tableRepository) PlanChanges(ctx context.Context, table models.Table) ([]models.SchemaChange, error) {
+	const op = "postgres.table.PlanChanges"
+
+	existing, err := r.columns(ctx, table.Schema, table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("%s: %s: %w", op, table.QualifiedName(), domain.ErrTableNotFound)
+	}
+
+	var changes []models.SchemaChange
+	for _, col := range table.Columns {
+		current, ok := sqlstore.FindColumn(existing, col.Name)
+		if !ok {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeAddColumn,
+				Column: col.Name,
+				To:     strings.ToLower(mapDataType(col.Type)),
+			})
+			continue
+		}
+
+		labels, err := r.enumLabels(ctx, table.Schema, current.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if to := widenedType(current.Type, labels, col); to != "" {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeWidenType,
+				Column: col.Name,
+				From:   current.Type,
+				To:     to,
+			})
+			continue
+		}
+
+		if holds, builtin := builtinTypes[current.Type]; !builtin || holds != nil {
+			continue
+		}
+
+		checks, err := r.enumChecks(ctx, table.Schema, table.Name, col.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if slices.ContainsFunc(checks, func(c enumCheck) bool { return !allowsValues(c.values, col) }) {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeDropCheck,
+				Column: col.Name,
+				From:   current.Type,
+				To:     current.Type,
+			})
+		}
+	}
+
+	if table.Lineage != nil {
+		changes = append(changes, lineage.Missing(existing)...)
+	}
+
+	return changes, nil
+}
+
+// ApplyChanges - add columns, widen types and drop enum CHECK restrictions in one transaction
+func (r *tableRepository) ApplyChanges(ctx context.Context, table models.Table, changes []models.SchemaChange) error {
+	const op = "postgres.table.ApplyChanges"
+	log := r.log.With("op", op)
+
+	quotedTableName := qualifiedName(table.Schema, table.Name)
+
+	var sb strings.Builder
+	for _, c := range changes {
+		// c.To is one of mapDataType or widening path types, not user input
+		quotedColumn := quoteIdentifier(c.Column)
+		switch c.Kind {
+		case models.SchemaChangeAddColumn:
+			fmt.Fprintf(&sb, "ALTER TABLE %s ADD COLUMN %s %s;", quotedTableName, quotedColumn, c.To)
+		case models.SchemaChangeWidenType:
+			fmt.Fprintf(&sb, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+				quotedTableName, quotedColumn, c.To, quotedColumn, c.To)
+		case models.SchemaChangeDropCheck:
+			checks, err := r.enumChecks(ctx, table.Schema, table.Name, c.Column)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			for _, check := range checks {
+				fmt.Fprintf(&sb, "ALTER TABLE %s DROP CONSTRAINT %s;", quotedTableName, quoteIdentifier(check.name))
+			}
+		default:
+			return fmt.Errorf("%s: unknown change %q", op, c.Kind)
+		}
+	}
+
+	query := sb.String()
+	log.Debug("ALTER query is ready", "query", query)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: failed to alter table %s: %w", op, table.QualifiedName(), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// enumLabels - return labels of enum type from schema of table (nil - type is not enum)
+func (r *tableRepository) enumLabels(ctx context.Context, schema, typeName string) ([]string, error) {
+	if _, builtin := builtinTypes[typeName]; builtin {
+		return nil, nil
+	}
+
+	query := `SELECT e.enumlabel FROM pg_enum e JOIN pg_type t ON t.oid = e.enumtypid
+JOIN pg_namespace n ON n.oid = t.typnamespace
+WHERE n.nspname = ` + schemaOrCurrent + ` AND t.typname = $2 ORDER BY e.enumsortorder;`
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, query, schema, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels of %s: %w", typeName, err)
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+
+	return labels, nil
+}
+
+// enumCheck - CHECK restriction of enum column (enum_mode: check)
+type enumCheck struct {
+	name   string
+	values []string
+}
+
+// enumCheckValue - string literal of definition "CHECK ((col = ANY (ARRAY['a'::text, 'b'::text])))"
+var enumCheckValue = regexp.MustCompile(`'((?:[^']|'')*)'::`)
+
+// enumChecks - return CHECK restrictions "col IN (...)" of column
+func (r *tableRepository) enumChecks(ctx context.Context, schema, table, column string) ([]enumCheck, error) {
+	query := `SELECT c.conname, pg_get_constraintdef(c.oid) FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = c.conkey[1]
+WHERE n.nspname = ` + schemaOrCurrent + ` AND t.relname = $2 AND a.attname = $3
+AND c.contype = 'c' AND cardinality(c.conkey) = 1;`
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, query, schema, table, column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restrictions of %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	var checks []enumCheck
+	for rows.Next() {
+		var name, definition string
+		if err := rows.Scan(&name, &definition); err != nil {
+			return nil, fmt.Errorf("failed to scan restriction: %w", err)
+		}
+		if !strings.Contains(definition, "= ANY (ARRAY[") {
+			continue
+		}
+
+		check := enumCheck{name: name}
+		for _, m := range enumCheckValue.FindAllStringSubmatch(definition, -1) {
+			check.values = append(check.values, strings.ReplaceAll(m[1], "''", "'"))
+		}
+		checks = append(checks, check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read restrictions: %w", err)
+	}
+
+	return checks, nil
+}
+
+// builtinTypes - types (information_schema data_type) which existing column may have, by values they hold
+var builtinTypes = map[string][]models.DataType{
+	"text":              nil,
+	"character varying": nil,
+	"character":         nil,
+	"smallint":          {models.DataTypeInteger},
+	"integer":           {models.DataTypeInteger},
+	"bigint":            {models.DataTypeInteger},
+	"numeric":           {models.DataTypeInteger, models.DataTypeFloat},
+	"double precision":  {models.DataTypeInteger, models.DataTypeFloat},
+	"real":              {models.DataTypeInteger, models.DataTypeFloat},
+	"boolean":           {models.DataTypeBoolean},
+	"uuid":              {models.DataTypeUUID},
+	"jsonb":             {models.DataTypeJSON},
+	"json":              {models.DataTypeJSON},
+	"inet":              {models.DataTypeInet, models.DataTypeCIDR},
+	"cidr":              {models.DataTypeCIDR},
+	"interval":          {models.DataTypeInterval},
+}
+
+// widenedType - return type to which column of dbType must be widened to hold values of col
+// ("" - no change needed or type is unknown)
+func widenedType(dbType string, enumLabels []string, col models.Column) string {
+	// column of file has only empty values
+	if col.Type == models.DataTypeUnknown || col.Profile.NullCount > 0 && col.Profile.DistinctCount == 0 {
+		return ""
+	}
+
+	holds, builtin := builtinTypes[dbType]
+	switch {
+	case builtin && holds == nil:
+		// text types hold any value
+		return ""
+	case !builtin && enumLabels == nil:
+		// unknown user-defined type (domain, extension), leave as is
+		return ""
+	case !builtin:
+		if len(col.EnumValues) > 0 && isSubset(col.EnumValues, enumLabels) {
+			return ""
+		}
+		return widenText
+	}
+
+	if slices.Contains(holds, col.Type) {
+		if col.Type == models.DataTypeInteger && !fitsInteger(dbType, col.Profile) {
+			return widenBigint
+		}
+		return ""
+	}
+
+	isInteger := dbType == "smallint" || dbType == "integer" || dbType == "bigint"
+	if isInteger && col.Type == models.DataTypeFloat {
+		return widenNumeric
+	}
+
+	return widenText
+}
+
+// fitsInteger - check that min and max of column fit into smallint/integer
+func fitsInteger(dbType string, profile models.ColumnProfile) bool {
+	var min, max int64
+	switch dbType {
+	case "smallint":
+		min, max = math.MinInt16, math.MaxInt16
+	case "integer":
+		min, max = math.MinInt32, math.MaxInt32
+	default:
+		return true
+	}
+
+	for _, v := range []string{profile.Min, profile.Max} {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < min || n > max {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsValues - check that enum CHECK restriction with values allows all values of col
+func allowsValues(values []string, col models.Column) bool {
+	// column of file has only empty values
+	if col.Type == models.DataTypeUnknown || col.Profile.NullCount > 0 && col.Profile.DistinctCount == 0 {
+		return true
+	}
+	return len(col.EnumValues) > 0 && isSubset(col.EnumValues, values)
+}
+
+func isSubset(values, set []string) bool {
+	for _, v := range values {
+		if !slices.Contains(set, v) {
+			return false
+		}
+	}
+	return true
+}
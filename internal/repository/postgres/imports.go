@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/schemajson"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+const selectImportsQuery = `SELECT id, filename, table_name, checksum, size_bytes, rows_count, columns_count,
+rejected_count, schema, duration_ms, status, error, COALESCE(idempotency_key, ''), created_at FROM imports`
+
+type importRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func newImportRepository(db *sql.DB, log *slog.Logger) *importRepository {
+	return &importRepository{db: db, log: log}
+}
+
+// Create - save import record in DB
+func (r *importRepository) Create(ctx context.Context, imp models.Import) (int64, error) {
+	const op = "postgres.imports.Create"
+
+	schema, err := json.Marshal(schemajson.NewTableSchema(imp.Schema))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to marshal schema: %w", op, err)
+	}
+
+	columns := []string{"filename", "table_name", "checksum", "size_bytes", "rows_count", "columns_count",
+		"rejected_count", "schema", "duration_ms", "status", "error"}
+	args := []any{
+		imp.Filename, imp.TableName, imp.Checksum, imp.Size, imp.Rows, imp.Columns,
+		imp.Rejected, schema, imp.Duration.Milliseconds(), string(imp.Status), imp.Error,
+	}
+
+	if imp.IdempotencyKey != "" {
+		columns = append(columns, "idempotency_key")
+		args = append(args, imp.IdempotencyKey)
+	}
+
+	// id is reserved by NextID
+	if imp.ID != 0 {
+		columns = append(columns, "id")
+		args = append(args, imp.ID)
+	}
+
+	placeholders := make([]string, len(args))
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO imports (%s) VALUES (%s) RETURNING id;",
+		strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	var id int64
+	err = sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to save import of %s: %w", op, imp.Filename, err)
+	}
+
+	return id, nil
+}
+
+// NextID - reserve id of import record from its sequence
+func (r *importRepository) NextID(ctx context.Context) (int64, error) {
+	const op = "postgres.imports.NextID"
+
+	var id int64
+	query := `SELECT nextval(pg_get_serial_sequence('imports', 'id'));`
+	if err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: failed to reserve import id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// LockKey - run fn holding advisory lock of idempotency key (hash of key, collision only serializes other key).
+// Lock is held by own transaction which does nothing else and is released by its rollback, fn uses ctx as is.
+// Inside Atomic lock is taken by its transaction, import is visible to others only after its commit
+func (r *importRepository) LockKey(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	const op = "postgres.imports.LockKey"
+	log := r.log.With("op", op)
+
+	const query = `SELECT pg_advisory_xact_lock(hashtext($1));`
+
+	if sqlstore.InAtomic(ctx) {
+		if _, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query, key); err != nil {
+			return fmt.Errorf("%s: failed to lock key %q: %w", op, key, err)
+		}
+		return fn(ctx)
+	}
+
+	// canceled request still records its import, lock must be held until fn returns
+	tx, err := r.db.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Warn("failed to release lock", slog.Any("err", err))
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("%s: failed to lock key %q: %w", op, key, err)
+	}
+
+	return fn(ctx)
+}
+
+// List - get import records from DB (newest first)
+func (r *importRepository) List(ctx context.Context, limit, offset int) ([]models.Import, error) {
+	const op = "postgres.imports.List"
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, selectImportsQuery+" ORDER BY id DESC LIMIT $1 OFFSET $2;", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list imports: %w", op, err)
+	}
+	defer rows.Close()
+
+	imports := make([]models.Import, 0, limit)
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		imports = append(imports, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read imports: %w", op, err)
+	}
+
+	return imports, nil
+}
+
+// Get - get import record from DB
+func (r *importRepository) Get(ctx context.Context, id int64) (models.Import, error) {
+	const op = "postgres.imports.Get"
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, selectImportsQuery+" WHERE id = $1;", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %d: %w", op, id, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+// FindByIdempotencyKey - get latest successful import with idempotency key made after since
+func (r *importRepository) FindByIdempotencyKey(ctx context.Context, key string, since time.Time) (models.Import, error) {
+	const op = "postgres.imports.FindByIdempotencyKey"
+
+	query := selectImportsQuery + ` WHERE idempotency_key = $1 AND status = 'success' AND created_at >= $2
+ORDER BY id DESC LIMIT 1;`
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, key, since))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %w", op, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+// FindByChecksum - get latest successful import of file with checksum to table made after since
+func (r *importRepository) FindByChecksum(ctx context.Context, tableName, checksum string, since time.Time) (models.Import, error) {
+	const op = "postgres.imports.FindByChecksum"
+
+	query := selectImportsQuery + ` WHERE table_name = $1 AND checksum = $2 AND status = 'success' AND created_at >= $3
+ORDER BY id DESC LIMIT 1;`
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, tableName, checksum, since))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %w", op, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanImport(row rowScanner) (models.Import, error) {
+	var (
+		imp        models.Import
+		schema     []byte
+		durationMs int64
+		status     string
+	)
+
+	err := row.Scan(&imp.ID, &imp.Filename, &imp.TableName, &imp.Checksum, &imp.Size, &imp.Rows, &imp.Columns,
+		&imp.Rejected, &schema, &durationMs, &status, &imp.Error, &imp.IdempotencyKey, &imp.CreatedAt)
+	if err != nil {
+		return models.Import{}, fmt.Errorf("failed to scan import: %w", err)
+	}
+
+	imp.Duration = time.Duration(durationMs) * time.Millisecond
+	imp.Status = models.ImportStatus(status)
+
+	if len(schema) > 0 {
+		var s schemajson.TableSchema
+		if err := json.Unmarshal(schema, &s); err != nil {
+			return models.Import{}, fmt.Errorf("failed to unmarshal schema: %w", err)
+		}
+		imp.Schema = s.ToModel()
+	}
+
+	return imp, nil
+}
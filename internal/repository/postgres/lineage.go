@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// lineage - lineage columns of Postgres table
+var lineage = sqlstore.Lineage{
+	{Name: "_import_id", Type: "BIGINT", Value: func(l *models.Lineage, _ int) any { return l.ImportID }},
+	{Name: "_source_file", Type: "TEXT", Value: func(l *models.Lineage, _ int) any { return l.SourceFile }},
+	{Name: "_source_sheet", Type: "TEXT", Value: func(l *models.Lineage, _ int) any { return sqlstore.NullString(l.SourceSheet) }},
+	{Name: "_source_row_number", Type: "BIGINT", Value: func(l *models.Lineage, i int) any { return l.RowNumber(i) }},
+	{Name: "_loaded_at", Type: "TIMESTAMPTZ", Value: func(l *models.Lineage, _ int) any { return l.LoadedAt }},
+}
@@ -2,12 +2,16 @@ package postgres_test
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
 	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 	"github.com/tmozzze/SQL_Converter/internal/repository/postgres"
 )
@@ -67,4 +71,397 @@ func TestSaveData(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// value rejected by DB is error of file, connection error is not
+	for code, rejected := range map[pq.ErrorCode]bool{"22P02": true, "23514": true, "57P01": false} {
+		mock.ExpectBegin()
+		mock.ExpectPrepare(`INSERT INTO "users"`)
+		mock.ExpectExec(`INSERT INTO "users"`).WillReturnError(&pq.Error{Code: code})
+		mock.ExpectRollback()
+
+		err := repo.Table().SaveData(ctx, table, data)
+		assert.Error(t, err)
+		assert.Equal(t, rejected, errors.Is(err, domain.ErrRowRejected), code)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTableWithEnums(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	columns := []models.Column{
+		{Name: "id", Type: models.DataTypeInteger},
+		{Name: "status", Type: models.DataTypeString, EnumValues: []string{"new", "paid"}},
+	}
+
+	t.Run("check constraint", func(t *testing.T) {
+		table := models.Table{Name: "orders", Columns: columns, EnumMode: models.EnumModeCheck}
+
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "orders" \("id" BIGINT, "status" TEXT CHECK \("status" IN \('new', 'paid'\)\)\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.Table().Create(ctx, table))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("enum type", func(t *testing.T) {
+		table := models.Table{Name: "orders", Columns: columns, EnumMode: models.EnumModeType}
+
+		mock.ExpectExec(`CREATE TYPE "orders_status_enum" AS ENUM \('new', 'paid'\);CREATE TABLE IF NOT EXISTS "orders" \("id" BIGINT, "status" "orders_status_enum"\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.Table().Create(ctx, table))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateTableWithComments(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+
+	table := models.Table{
+		Name: "payments",
+		Columns: []models.Column{
+			{Name: "id", Source: "id", Type: models.DataTypeInteger},
+			{Name: "data_oplaty", Source: "Дата оплаты", Type: models.DataTypeString},
+		},
+	}
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "payments" \("id" BIGINT, "data_oplaty" TEXT\);` +
+		`COMMENT ON COLUMN "payments"."data_oplaty" IS 'Дата оплаты';$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.Table().Create(context.Background(), table))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDataTolerant(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	table := models.Table{
+		Name: "users",
+		Columns: []models.Column{
+			{Name: "id", Type: models.DataTypeInteger},
+			{Name: "name", Type: models.DataTypeString},
+		},
+	}
+
+	data := [][]string{{"1", "John"}, {"99999999999999999999", "Jane"}, {"3", "Jim"}}
+
+	t.Run("failed row is rejected", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectPrepare(`INSERT INTO "users" \("id", "name"\) VALUES \(\$1, \$2\);`)
+		mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "users"`).WithArgs("1", "John").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "users"`).WithArgs("99999999999999999999", "Jane").WillReturnError(errors.New("bigint out of range"))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "users"`).WithArgs("3", "Jim").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rejects, err := repo.Table().SaveDataTolerant(ctx, table, data, 1)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		require.Len(t, rejects, 1)
+		assert.Equal(t, 2, rejects[0].Row)
+		assert.Equal(t, "bigint out of range", rejects[0].Reason)
+	})
+
+	t.Run("too many failed rows", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectPrepare(`INSERT INTO "users"`)
+		mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "users"`).WithArgs("1", "John").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "users"`).WithArgs("99999999999999999999", "Jane").WillReturnError(errors.New("bigint out of range"))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.Table().SaveDataTolerant(ctx, table, data, 0)
+		assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSaveRejects(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	table := models.Table{Name: "users", Schema: "finance"}
+	rejects := []models.RejectedRow{{Row: 2, Column: "id", Value: "abc", Reason: "invalid Integer value", Values: []string{"abc"}}}
+
+	t.Run("append keeps rejects of earlier imports", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS "finance"."users_rejects" \(import_id BIGINT, row_number BIGINT, `).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "finance"."users_rejects" \(import_id, row_number, column_name, value, reason, row_data\)`)
+		mock.ExpectExec(`INSERT INTO "finance"."users_rejects"`).
+			WithArgs(int64(7), 2, "id", "abc", "invalid Integer value", []byte(`["abc"]`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Table().SaveRejects(ctx, table, 7, false, rejects))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replace recreates table", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^DROP TABLE IF EXISTS "finance"."users_rejects";CREATE TABLE IF NOT EXISTS "finance"."users_rejects"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "finance"."users_rejects"`)
+		mock.ExpectExec(`INSERT INTO "finance"."users_rejects"`).
+			WithArgs(int64(8), 2, "id", "abc", "invalid Integer value", []byte(`["abc"]`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Table().SaveRejects(ctx, table, 8, true, rejects))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRenameTable(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	t.Run("table with rejects and profile is renamed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT schema_name FROM managed_tables WHERE .* AND name = \$2 FOR UPDATE;`).
+			WithArgs("", "sales").
+			WillReturnRows(sqlmock.NewRows([]string{"schema_name"}).AddRow("public"))
+		mock.ExpectExec(`ALTER TABLE "public"."sales" RENAME TO "sales_2024";ALTER TABLE IF EXISTS "public"."sales_rejects" RENAME TO "sales_2024_rejects";`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE managed_tables SET name = \$3`).
+			WithArgs("public", "sales", "sales_2024").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE table_profiles SET table_name = \$2`).
+			WithArgs("sales", "sales_2024").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Table().Rename(ctx, "sales", "sales_2024")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("table not created by service is not renamed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT schema_name FROM managed_tables WHERE .* AND name = \$2 FOR UPDATE;`).
+			WithArgs("", "pg_users").
+			WillReturnRows(sqlmock.NewRows([]string{"schema_name"}))
+		mock.ExpectRollback()
+
+		err := repo.Table().Rename(ctx, "pg_users", "users")
+
+		assert.ErrorIs(t, err, domain.ErrTableNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDropTable(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT schema_name FROM managed_tables WHERE .* AND name = \$2 FOR UPDATE;`).
+		WithArgs("finance", "sales").
+		WillReturnRows(sqlmock.NewRows([]string{"schema_name"}).AddRow("finance"))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "finance"."sales" CASCADE;DROP TABLE IF EXISTS "finance"."sales_rejects";`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM managed_tables WHERE schema_name = \$1 AND name = \$2;`).
+		WithArgs("finance", "sales").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM table_profiles WHERE table_name = \$1;`).
+		WithArgs("finance.sales").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Table().Drop(ctx, "finance.sales")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTableRows(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+
+	expectColumns := func() {
+		mock.ExpectQuery(`SELECT schema_name FROM managed_tables WHERE .* AND name = \$2;`).
+			WithArgs("", "users").
+			WillReturnRows(sqlmock.NewRows([]string{"schema_name"}).AddRow("public"))
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("public", "users").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("id", "bigint").
+				AddRow("name", "text").
+				AddRow("city", "text"))
+	}
+
+	t.Run("selected columns with filter and cursor", func(t *testing.T) {
+		expectColumns()
+		mock.ExpectQuery(`SELECT ctid::text, "name", "id" FROM "public"."users" WHERE "city"::text = \$1 AND ctid > \$2::tid ORDER BY ctid LIMIT \$3 OFFSET \$4;`).
+			WithArgs("Moscow", "(0,2)", 3, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"ctid", "name", "id"}).
+				AddRow("(0,3)", []byte("John"), int64(3)).
+				AddRow("(0,5)", "Jane", int64(5)).
+				AddRow("(0,8)", "Jack", int64(8)))
+
+		page, err := repo.Table().Rows(ctx, "users", models.RowsQuery{
+			Columns: []string{"name", "id"},
+			Filters: []models.Filter{{Column: "city", Value: "Moscow"}},
+			Limit:   2,
+			After:   "(0,2)",
+		})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, [][]any{{"John", int64(3)}, {"Jane", int64(5)}}, page.Rows)
+		assert.Equal(t, "(0,5)", page.NextCursor)
+		assert.Equal(t, "name", page.Columns[0].Name)
+	})
+
+	t.Run("unknown filter column", func(t *testing.T) {
+		expectColumns()
+
+		_, err := repo.Table().Rows(ctx, "users", models.RowsQuery{
+			Filters: []models.Filter{{Column: "age; DROP TABLE users", Value: "1"}},
+			Limit:   10,
+		})
+
+		assert.ErrorIs(t, err, domain.ErrColumnNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		expectColumns()
+
+		_, err := repo.Table().Rows(ctx, "users", models.RowsQuery{Limit: 10, After: "0,1"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLockKey(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+	lockQuery := `SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\);`
+
+	t.Run("lock is held by own transaction until fn returns", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockQuery).WithArgs("key-1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT nextval`).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(7))
+		mock.ExpectRollback()
+
+		err := repo.Import().LockKey(ctx, "key-1", func(ctx context.Context) error {
+			_, err := repo.Import().NextID(ctx)
+			return err
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("inside Atomic lock is taken by its transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockQuery).WithArgs("key-1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Atomic(ctx, func(ctx context.Context) error {
+			return repo.Import().LockKey(ctx, "key-1", func(ctx context.Context) error { return nil })
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fn is not called if lock failed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(lockQuery).WithArgs("key-1").WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		called := false
+		err := repo.Import().LockKey(ctx, "key-1", func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+		assert.Error(t, err)
+		assert.False(t, called)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateNew(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	repo := postgres.NewRepository(db, log)
+	ctx := context.Background()
+	table := models.Table{Name: "sales", Schema: "reporting", Columns: []models.Column{{Name: "id", Type: models.DataTypeInteger}}}
+
+	t.Run("name is taken before table is created", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "reporting";CREATE TABLE "reporting"."sales" \(\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DROP TABLE IF EXISTS "reporting"."sales" CASCADE;CREATE TABLE IF NOT EXISTS "reporting"."sales" \("id" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Table().CreateNew(ctx, table))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("name created by concurrent transaction is taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE "reporting"."sales" \(\);`).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "pg_type_typname_nsp_index"})
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Table().CreateNew(ctx, table)
+		assert.ErrorIs(t, err, domain.ErrTableExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// cursorRegexp - physical row position (ctid) used as keyset cursor.
+// Cursor is best-effort: UPDATE, VACUUM FULL or CLUSTER move rows, so pages read across
+// such change may skip or repeat rows. Loaded tables are append-only, and replace recreates table
+// (old cursor points to new rows)
+var cursorRegexp = regexp.MustCompile(`^\(\d+,\d+\)$`)
+
+// Rows - get page of rows ordered by physical position (ctid)
+func (r *tableRepository) Rows(ctx context.Context, name string, query models.RowsQuery) (models.RowsPage, error) {
+	const op = "postgres.table.Rows"
+
+	schema, table, err := r.managedTable(ctx, name)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tableColumns, err := r.columns(ctx, schema, table)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(tableColumns) == 0 {
+		return models.RowsPage{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	selected, err := sqlstore.SelectColumns(tableColumns, query.Columns)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sqlQuery, args, err := buildRowsQuery(qualifiedName(schema, table), selected, tableColumns, query)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page, err := sqlstore.QueryPage(ctx, sqlstore.Conn(ctx, r.db), sqlQuery, args, selected, query.Limit)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	return page, nil
+}
+
+// buildRowsQuery - build SELECT with filters and pagination, first selected value is ctid
+func buildRowsQuery(quotedTableName string, selected, tableColumns []models.ColumnInfo, query models.RowsQuery) (string, []any, error) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("SELECT ctid::text")
+	for _, col := range selected {
+		sb.WriteString(", ")
+		sb.WriteString(quoteIdentifier(col.Name))
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(quotedTableName)
+
+	var conditions []string
+	for _, f := range query.Filters {
+		if _, ok := sqlstore.FindColumn(tableColumns, f.Column); !ok {
+			return "", nil, fmt.Errorf("%s: %w", f.Column, domain.ErrColumnNotFound)
+		}
+		args = append(args, f.Value)
+		conditions = append(conditions, fmt.Sprintf("%s::text = $%d", quoteIdentifier(f.Column), len(args)))
+	}
+
+	if query.After != "" {
+		if !cursorRegexp.MatchString(query.After) {
+			return "", nil, fmt.Errorf("%q: %w", query.After, domain.ErrInvalidCursor)
+		}
+		args = append(args, query.After)
+		conditions = append(conditions, fmt.Sprintf("ctid > $%d::tid", len(args)))
+	}
+
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(conditions, " AND "))
+	}
+
+	args = append(args, query.Limit+1, query.Offset)
+	sb.WriteString(fmt.Sprintf(" ORDER BY ctid LIMIT $%d OFFSET $%d;", len(args)-1, len(args)))
+
+	return sb.String(), args, nil
+}
+
+// Export - stream all rows of table (created by any tool) to w
+func (r *tableRepository) Export(ctx context.Context, name string, w domain.RowWriter) (int64, error) {
+	const op = "postgres.table.Export"
+
+	schema, table := models.SplitQualifiedName(name)
+
+	columns, err := r.columns(ctx, schema, table)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	quotedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = quoteIdentifier(col.Name)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s;", strings.Join(quotedColumns, ", "), qualifiedName(schema, table))
+
+	count, err := sqlstore.Export(ctx, sqlstore.Conn(ctx, r.db), query, columns, w)
+	if err != nil {
+		return count, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	return count, nil
+}
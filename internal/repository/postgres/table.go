@@ -3,11 +3,16 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
+	"github.com/lib/pq"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
 	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
 )
 
 type tableRepository struct {
@@ -24,118 +29,189 @@ func (r *tableRepository) Create(ctx context.Context, table models.Table) error
 	const op = "postgres.table.Create"
 	log := r.log.With("op", op)
 
-	// Generate query
-	var sb strings.Builder
+	query := buildCreateQuery(table)
 
-	// Delete table
-	quotedTableName := quoteIdentifier(table.Name)
-	sb.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", quotedTableName))
-
-	sb.WriteString("CREATE TABLE IF NOT EXISTS ")
-	sb.WriteString(quotedTableName)
-	sb.WriteString(" (")
+	// Go to DB
 
-	for i, col := range table.Columns {
-		if i > 0 {
-			sb.WriteString(", ")
-		}
+	log.Debug("CREATE query is ready", "query", query)
 
-		sb.WriteString(quoteIdentifier(col.Name))
-		sb.WriteString(" ")
-		sb.WriteString(mapDataType(col.Type))
+	_, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to create table %s: %w", op, table.QualifiedName(), err)
 	}
 
-	sb.WriteString(");")
+	return nil
+}
 
-	query := sb.String()
+// CreateNew - take name by plain CREATE TABLE without columns (concurrent creation of the name waits
+// for this transaction and fails), then create table as usual in the same transaction
+func (r *tableRepository) CreateNew(ctx context.Context, table models.Table) error {
+	const op = "postgres.table.CreateNew"
+	log := r.log.With("op", op)
 
-	// Go to DB
+	return sqlstore.Atomic(ctx, r.db, r.log, func(ctx context.Context) error {
+		// savepoint: failed statement must not abort transaction of caller
+		tx, err := sqlstore.BeginTx(ctx, r.db)
+		if err != nil {
+			return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+		}
+		defer func() {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				log.Debug("rollback failed", slog.Any("err", err))
+			}
+		}()
+
+		query := fmt.Sprintf("CREATE TABLE %s ();", qualifiedName(table.Schema, table.Name))
+		if table.Schema != "" {
+			query = fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", quoteIdentifier(table.Schema)) + query
+		}
 
-	log.Debug("CREATE query is ready", "query", query)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			if nameTaken(err) {
+				return fmt.Errorf("%s: %s: %w", op, table.QualifiedName(), domain.ErrTableExists)
+			}
+			return fmt.Errorf("%s: failed to create table %s: %w", op, table.QualifiedName(), err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+		}
 
-	_, err := r.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("%s: failed to create table %s: %w", op, table.Name, err)
+		return r.Create(ctx, table)
+	})
+}
+
+// nameTaken - check if CREATE TABLE failed because name is taken by existing relation
+// or by concurrent transaction (unique index of catalog)
+func nameTaken(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
 	}
 
-	return nil
+	switch pqErr.Code {
+	case "42P07": // duplicate_table
+		return true
+	case "23505": // unique_violation
+		return pqErr.Constraint == "pg_type_typname_nsp_index" || pqErr.Constraint == "pg_class_relname_nsp_index"
+	}
+	return false
 }
 
 // SaveData - save data in DB
 func (r *tableRepository) SaveData(ctx context.Context, table models.Table, data [][]string) error {
-	const op = "postgres.table.Create"
-	log := r.log.With("op", op)
+	const op = "postgres.table.SaveData"
 
-	if len(data) == 0 {
-		return nil
+	if err := sqlstore.Insert(ctx, r.db, r.log, buildInsertQuery(table), data, rowArgs(table)); err != nil {
+		if dataError(err) {
+			return fmt.Errorf("%s: %w: %w", op, domain.ErrRowRejected, err)
+		}
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
-	}
-	defer func() {
-		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Debug("rollback failed", slog.Any("err", err))
-		}
-	}()
+	return nil
+}
 
-	query := buildInsertQuery(table)
+// SaveDataTolerant - save data in DB, failed rows are rolled back to savepoint and returned
+func (r *tableRepository) SaveDataTolerant(ctx context.Context, table models.Table, data [][]string, maxErrors int) ([]models.RejectedRow, error) {
+	const op = "postgres.table.SaveDataTolerant"
 
-	stmt, err := tx.PrepareContext(ctx, query)
+	rejects, err := sqlstore.InsertTolerant(ctx, r.db, r.log, buildInsertQuery(table), data, rowArgs(table), maxErrors, errorColumn)
 	if err != nil {
-		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
 
-	for i, row := range data {
-		args := make([]any, len(row))
-		for j, v := range row {
-			args[j] = v
-		}
+	return rejects, nil
+}
 
-		if _, err := stmt.ExecContext(ctx, args...); err != nil {
-			return fmt.Errorf("%s: failed to insert row %d: %w", op, i+1, err)
-		}
+// dataError - report whether row is rejected because of its values:
+// data exception (class 22) or integrity constraint violation (class 23)
+func dataError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
 	}
+	class := pqErr.Code.Class()
+	return class == "22" || class == "23"
+}
 
-	// Go to DB
+// errorColumn - return column name from Postgres error if it is known
+func errorColumn(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Column
+	}
+	return ""
+}
 
-	log.Debug("INSERT query is ready", "query", query)
+// SaveRejects - save rejected rows of import in <table>_rejects (recreated in replace mode)
+func (r *tableRepository) SaveRejects(ctx context.Context, table models.Table, importID int64, replace bool, rejects []models.RejectedRow) error {
+	const op = "postgres.table.SaveRejects"
+	log := r.log.With("op", op)
+
+	quotedRejectsName := qualifiedName(table.Schema, rejectsTableName(table.Name))
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	createQuery := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s "+
+		"(import_id BIGINT, row_number BIGINT, column_name TEXT, value TEXT, reason TEXT, row_data JSONB);",
+		quotedRejectsName)
+	if replace {
+		createQuery = fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedRejectsName) + createQuery
 	}
+	insertQuery := fmt.Sprintf("INSERT INTO %s (import_id, row_number, column_name, value, reason, row_data) "+
+		"VALUES ($1, $2, $3, $4, $5, $6);", quotedRejectsName)
+
+	err := sqlstore.InsertRejects(ctx, r.db, r.log, createQuery, insertQuery, importID, rejects, func(data []byte) any { return data })
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("rejected rows saved", "table", rejectsTableName(table.QualifiedName()), "count", len(rejects))
 
 	return nil
 }
 
+// rejectsTableName - return name of table for rejected rows
+func rejectsTableName(tableName string) string {
+	return tableName + "_rejects"
+}
+
 func quoteIdentifier(name string) string {
-	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	return pg.QuoteIdentifier(name)
 }
 
-func buildInsertQuery(table models.Table) string {
-	var sb strings.Builder
-	sb.WriteString("INSERT INTO ")
-	sb.WriteString(quoteIdentifier(table.Name))
-	sb.WriteString(" (")
-
-	// Columns
-	for i, col := range table.Columns {
-		if i > 0 {
-			sb.WriteString(", ")
-		}
-		sb.WriteString(quoteIdentifier(col.Name))
+// qualifiedName - return quoted "schema"."name" ("name" for default search_path)
+func qualifiedName(schema, name string) string {
+	return pg.QualifiedName(schema, name)
+}
+
+// buildCreateQuery - return statements which (re)create table with its schema and enum types
+func buildCreateQuery(table models.Table) string {
+	return strings.Join(createStatements(table), "")
+}
+
+// createStatements - return statements (with ;) which (re)create table with its schema, enum types and lineage columns
+func createStatements(table models.Table) []string {
+	stmts := pg.CreateTable(table, lineage.Definitions(table)...)
+
+	// batch of import can be found (and deleted) by _import_id
+	if table.Lineage != nil {
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX ON %s (%s);",
+			qualifiedName(table.Schema, table.Name), quoteIdentifier(lineage[0].Name)))
 	}
 
-	sb.WriteString(") VALUES (")
+	return stmts
+}
 
-	for i := range table.Columns {
-		if i > 0 {
-			sb.WriteString(", ")
+// rowArgs - return query arguments of saved rows with lineage values
+func rowArgs(table models.Table) sqlstore.RowArgs {
+	return func(row []string, i int) []any {
+		args := make([]any, len(row), len(row)+len(lineage))
+		for j, v := range row {
+			args[j] = dialect.Arg(table, j, v)
 		}
-		fmt.Fprintf(&sb, "$%d", i+1)
+		return lineage.Append(args, table, i)
 	}
-	sb.WriteString(");")
-	return sb.String()
+}
+
+func buildInsertQuery(table models.Table) string {
+	return pg.InsertQuery(table, lineage.Definitions(table)...)
 }
@@ -1,18 +1,13 @@
 package postgres
 
-import "github.com/tmozzze/SQL_Converter/internal/domain/models"
+import (
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+)
+
+// pg - SQL syntax of Postgres
+var pg = dialect.Postgres
 
 func mapDataType(t models.DataType) string {
-	switch t {
-	case models.DataTypeInteger:
-		return "BIGINT"
-	case models.DataTypeFloat:
-		return "NUMERIC"
-	case models.DataTypeBoolean:
-		return "BOOLEAN"
-	case models.DataTypeString:
-		return "TEXT"
-	default:
-		return "TEXT"
-	}
+	return pg.ColumnType(t)
 }
@@ -0,0 +1,138 @@
+// Package schemajson - JSON form of analyzed table schema stored by repositories (imports, profiles)
+package schemajson
+
+import "github.com/tmozzze/SQL_Converter/internal/domain/models"
+
+// TableSchema - JSON representation of models.Table
+type TableSchema struct {
+	Schema   string         `json:"schema,omitempty"`
+	Name     string         `json:"name"`
+	EnumMode string         `json:"enum_mode,omitempty"`
+	Columns  []columnSchema `json:"columns"`
+}
+
+type columnSchema struct {
+	Name       string         `json:"name"`
+	Source     string         `json:"source,omitempty"`
+	Type       string         `json:"type"`
+	EnumValues []string       `json:"enum_values,omitempty"`
+	Confidence float64        `json:"confidence"`
+	Downgrades []typeChange   `json:"downgrades,omitempty"`
+	Profile    *columnProfile `json:"profile,omitempty"`
+}
+
+type typeChange struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value string `json:"value"`
+	Row   int    `json:"row"`
+}
+
+type columnProfile struct {
+	NullCount     int          `json:"null_count"`
+	DistinctCount int          `json:"distinct_count"`
+	Min           string       `json:"min,omitempty"`
+	Max           string       `json:"max,omitempty"`
+	Mean          *float64     `json:"mean,omitempty"`
+	MaxLength     int          `json:"max_length"`
+	TopValues     []valueCount `json:"top_values,omitempty"`
+	Samples       []string     `json:"samples,omitempty"`
+}
+
+type valueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// NewTableSchema - return JSON representation of table
+func NewTableSchema(table models.Table) TableSchema {
+	schema := TableSchema{
+		Schema:   table.Schema,
+		Name:     table.Name,
+		EnumMode: string(table.EnumMode),
+		Columns:  make([]columnSchema, len(table.Columns)),
+	}
+
+	for i, col := range table.Columns {
+		p := col.Profile
+		top := make([]valueCount, len(p.TopValues))
+		for j, v := range p.TopValues {
+			top[j] = valueCount{Value: v.Value, Count: v.Count}
+		}
+
+		downgrades := make([]typeChange, len(col.Downgrades))
+		for j, d := range col.Downgrades {
+			downgrades[j] = typeChange{From: d.From.String(), To: d.To.String(), Value: d.Value, Row: d.Row}
+		}
+
+		schema.Columns[i] = columnSchema{
+			Name:       col.Name,
+			Source:     col.Source,
+			Type:       col.Type.String(),
+			EnumValues: col.EnumValues,
+			Confidence: col.Confidence,
+			Downgrades: downgrades,
+			Profile: &columnProfile{
+				NullCount:     p.NullCount,
+				DistinctCount: p.DistinctCount,
+				Min:           p.Min,
+				Max:           p.Max,
+				Mean:          p.Mean,
+				MaxLength:     p.MaxLength,
+				TopValues:     top,
+				Samples:       p.Samples,
+			},
+		}
+	}
+
+	return schema
+}
+
+// ToModel - return table of JSON representation
+func (s TableSchema) ToModel() models.Table {
+	table := models.Table{
+		Schema:   s.Schema,
+		Name:     s.Name,
+		EnumMode: models.EnumMode(s.EnumMode),
+		Columns:  make([]models.Column, len(s.Columns)),
+	}
+
+	for i, col := range s.Columns {
+		table.Columns[i] = models.Column{
+			Name:       col.Name,
+			Source:     col.Source,
+			Type:       models.ParseDataType(col.Type),
+			EnumValues: col.EnumValues,
+			Confidence: col.Confidence,
+		}
+
+		for _, d := range col.Downgrades {
+			table.Columns[i].Downgrades = append(table.Columns[i].Downgrades, models.TypeChange{
+				From:  models.ParseDataType(d.From),
+				To:    models.ParseDataType(d.To),
+				Value: d.Value,
+				Row:   d.Row,
+			})
+		}
+
+		if p := col.Profile; p != nil {
+			top := make([]models.ValueCount, len(p.TopValues))
+			for j, v := range p.TopValues {
+				top[j] = models.ValueCount{Value: v.Value, Count: v.Count}
+			}
+
+			table.Columns[i].Profile = models.ColumnProfile{
+				NullCount:     p.NullCount,
+				DistinctCount: p.DistinctCount,
+				Min:           p.Min,
+				Max:           p.Max,
+				Mean:          p.Mean,
+				MaxLength:     p.MaxLength,
+				TopValues:     top,
+				Samples:       p.Samples,
+			}
+		}
+	}
+
+	return table
+}
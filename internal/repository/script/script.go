@@ -0,0 +1,100 @@
+// Package script - renders tables as SQL scripts of supported dialects (offline, without DB)
+package script
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+)
+
+type scriptRepository struct {
+	log *slog.Logger
+}
+
+// NewRepository - constructor for ScriptRepository
+func NewRepository(log *slog.Logger) domain.ScriptRepository {
+	return &scriptRepository{log: log}
+}
+
+// WriteScript - write transaction (if dialect has them) with CREATE TABLE and multi-row INSERT statements to w
+func (r *scriptRepository) WriteScript(ctx context.Context, w io.Writer, name models.SQLDialect, table models.Table, data [][]string) error {
+	const op = "script.WriteScript"
+	log := r.log.With("op", op)
+
+	d, err := dialect.ByName(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	bulk := d.BulkLoad()
+
+	bw := bufio.NewWriter(w)
+
+	if bulk.Begin != "" {
+		bw.WriteString(bulk.Begin)
+		bw.WriteString("\n\n")
+	}
+	for _, stmt := range d.CreateTable(table) {
+		bw.WriteString(stmt)
+		bw.WriteString("\n")
+	}
+
+	quotedColumns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		quotedColumns[i] = d.QuoteIdentifier(col.Name)
+	}
+	insert := fmt.Sprintf("\nINSERT INTO %s (%s) VALUES\n",
+		d.QualifiedName(table.Schema, table.Name), strings.Join(quotedColumns, ", "))
+
+	for start := 0; start < len(data); start += bulk.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		end := min(start+bulk.BatchSize, len(data))
+
+		bw.WriteString(insert)
+		for i, row := range data[start:end] {
+			if i > 0 {
+				bw.WriteString(",\n")
+			}
+			bw.WriteString("(")
+			for j, v := range row {
+				if j > 0 {
+					bw.WriteString(", ")
+				}
+				bw.WriteString(d.Literal(dialect.Arg(table, j, v), columnType(table, j)))
+			}
+			bw.WriteString(")")
+		}
+		bw.WriteString(";\n")
+	}
+
+	if bulk.Commit != "" {
+		bw.WriteString("\n")
+		bw.WriteString(bulk.Commit)
+		bw.WriteString("\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("%s: failed to write script of %s: %w", op, table.QualifiedName(), err)
+	}
+
+	log.Debug("script is written", "table", table.QualifiedName(), "dialect", d.Name(), "rows", len(data))
+
+	return nil
+}
+
+// columnType - return data type of column j (string for values beyond columns)
+func columnType(table models.Table, j int) models.DataType {
+	if j >= len(table.Columns) {
+		return models.DataTypeString
+	}
+	return table.Columns[j].Type
+}
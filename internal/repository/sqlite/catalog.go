@@ -0,0 +1,304 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// Register - mark table as created by service in managed_tables
+func (r *tableRepository) Register(ctx context.Context, name string, importID int64) error {
+	const op = "sqlite.table.Register"
+
+	table, err := splitName(name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `INSERT INTO managed_tables (schema_name, name, last_import_id) VALUES (?, ?, ?)
+ON CONFLICT (schema_name, name) DO UPDATE SET last_import_id = excluded.last_import_id, updated_at = CURRENT_TIMESTAMP;`
+
+	// 0 - import was not recorded
+	lastImportID := sql.NullInt64{Int64: importID, Valid: importID != 0}
+
+	if _, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query, mainSchema, table, lastImportID); err != nil {
+		return fmt.Errorf("%s: failed to register table %s: %w", op, name, err)
+	}
+
+	return nil
+}
+
+// List - list tables from managed_tables with exact rows and size (SQLite has no statistics)
+func (r *tableRepository) List(ctx context.Context, filter models.TableFilter) ([]models.TableInfo, error) {
+	const op = "sqlite.table.List"
+
+	if filter.Schema != "" && filter.Schema != mainSchema {
+		return []models.TableInfo{}, nil
+	}
+
+	query := `SELECT schema_name, name, created_at, updated_at FROM managed_tables
+WHERE name LIKE ? ESCAPE '\'
+ORDER BY name LIMIT ? OFFSET ?;`
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, query, likePattern(filter.Name), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list tables: %w", op, err)
+	}
+	defer rows.Close()
+
+	tables := make([]models.TableInfo, 0, filter.Limit)
+	for rows.Next() {
+		var t models.TableInfo
+		if err := rows.Scan(&t.Schema, &t.Name, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan table: %w", op, err)
+		}
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read tables: %w", op, err)
+	}
+	// pool has one connection, it must be free for counting
+	rows.Close()
+
+	for i := range tables {
+		if err := r.stats(ctx, &tables[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return tables, nil
+}
+
+// Describe - get columns, row count and size of table
+func (r *tableRepository) Describe(ctx context.Context, name string) (models.TableInfo, error) {
+	const op = "sqlite.table.Describe"
+
+	table, err := splitName(name)
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+	info := models.TableInfo{Name: table}
+
+	query := `SELECT schema_name, created_at, updated_at FROM managed_tables WHERE schema_name = ? AND name = ?;`
+
+	err = sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, mainSchema, table).Scan(&info.Schema, &info.CreatedAt, &info.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TableInfo{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: failed to get table %s: %w", op, name, err)
+	}
+
+	info.Columns, err = columns(ctx, sqlstore.Conn(ctx, r.db), table)
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// registered, but dropped outside of service
+	if len(info.Columns) == 0 {
+		return models.TableInfo{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	if err := r.stats(ctx, &info); err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return info, nil
+}
+
+// stats - set row count and size (pages of table in dbstat) of table
+func (r *tableRepository) stats(ctx context.Context, info *models.TableInfo) error {
+	exists, err := tableExists(ctx, sqlstore.Conn(ctx, r.db), info.Name)
+	if err != nil || !exists {
+		return err
+	}
+
+	countQuery := fmt.Sprintf("SELECT count(*) FROM %s;", quoteIdentifier(info.Name))
+	if err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, countQuery).Scan(&info.Rows); err != nil {
+		return fmt.Errorf("failed to count rows of %s: %w", info.Name, err)
+	}
+
+	sizeQuery := `SELECT COALESCE(SUM(pgsize), 0) FROM dbstat WHERE name = ?;`
+	if err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, sizeQuery, info.Name).Scan(&info.SizeBytes); err != nil {
+		return fmt.Errorf("failed to get size of %s: %w", info.Name, err)
+	}
+
+	return nil
+}
+
+// Rename - rename table with its rejects table, catalog record and profile
+func (r *tableRepository) Rename(ctx context.Context, name, newName string) error {
+	const op = "sqlite.table.Rename"
+	log := r.log.With("op", op)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	table, err := managedTable(ctx, tx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	exists, err := tableExists(ctx, tx, newName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return fmt.Errorf("%s: %s: %w", op, newName, domain.ErrTableExists)
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdentifier(table), quoteIdentifier(newName))
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: failed to rename table %s: %w", op, name, err)
+	}
+
+	rejectsExists, err := tableExists(ctx, tx, rejectsTableName(table))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rejectsExists {
+		rejectsQuery := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;",
+			quoteIdentifier(rejectsTableName(table)), quoteIdentifier(rejectsTableName(newName)))
+		if _, err := tx.ExecContext(ctx, rejectsQuery); err != nil {
+			return fmt.Errorf("%s: failed to rename rejects table of %s: %w", op, name, err)
+		}
+	}
+
+	catalogQuery := `UPDATE managed_tables SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE schema_name = ? AND name = ?;`
+	if _, err := tx.ExecContext(ctx, catalogQuery, newName, mainSchema, table); err != nil {
+		return fmt.Errorf("%s: failed to update catalog: %w", op, err)
+	}
+
+	// profile key keeps the form it was saved with on upload
+	requestedSchema, _ := models.SplitQualifiedName(name)
+	newProfileName := models.Table{Schema: requestedSchema, Name: newName}.QualifiedName()
+
+	profileQuery := `UPDATE table_profiles SET table_name = ? WHERE table_name = ?;`
+	if _, err := tx.ExecContext(ctx, profileQuery, newProfileName, name); err != nil {
+		return fmt.Errorf("%s: failed to update profile: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// Drop - drop table with its rejects table, catalog record and profile
+func (r *tableRepository) Drop(ctx context.Context, name string) error {
+	const op = "sqlite.table.Drop"
+	log := r.log.With("op", op)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	table, err := managedTable(ctx, tx, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := fmt.Sprintf("DROP TABLE IF EXISTS %s;DROP TABLE IF EXISTS %s;",
+		quoteIdentifier(table), quoteIdentifier(rejectsTableName(table)))
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: failed to drop table %s: %w", op, name, err)
+	}
+
+	catalogQuery := `DELETE FROM managed_tables WHERE schema_name = ? AND name = ?;`
+	if _, err := tx.ExecContext(ctx, catalogQuery, mainSchema, table); err != nil {
+		return fmt.Errorf("%s: failed to update catalog: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM table_profiles WHERE table_name = ?;`, name); err != nil {
+		return fmt.Errorf("%s: failed to delete profile: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// columns - get columns of table with declared types (lower case)
+func columns(ctx context.Context, q sqlstore.Querier, name string) ([]models.ColumnInfo, error) {
+	rows, err := q.QueryContext(ctx, `SELECT name, type FROM pragma_table_info(?) ORDER BY cid;`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnInfo
+	for rows.Next() {
+		var col models.ColumnInfo
+		if err := rows.Scan(&col.Name, &col.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		col.Type = strings.ToLower(col.Type)
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	return columns, nil
+}
+
+// managedTable - return name of table created by service, ErrTableNotFound if table is not created by service
+// (write transaction of SQLite locks the whole DB, record needs no lock)
+func managedTable(ctx context.Context, q sqlstore.Querier, name string) (string, error) {
+	table, err := splitName(name)
+	if err != nil {
+		return "", err
+	}
+
+	var found string
+	query := `SELECT name FROM managed_tables WHERE schema_name = ? AND name = ?;`
+
+	err = q.QueryRowContext(ctx, query, mainSchema, table).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", name, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get table %s: %w", name, err)
+	}
+
+	return found, nil
+}
+
+// splitName - return table name of "main.name" or "name"
+func splitName(name string) (string, error) {
+	schema, table := models.SplitQualifiedName(name)
+	if err := checkSchema(schema); err != nil {
+		return "", err
+	}
+	return table, nil
+}
+
+// likePattern - return LIKE pattern for substring search (LIKE of SQLite ignores case of ASCII)
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
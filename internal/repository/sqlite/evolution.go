@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// Types of widening path INTEGER --> REAL --> TEXT
+const (
+	widenReal = "real"
+	widenText = "text"
+)
+
+// affinityHolds - data types which column of type affinity holds (nil - any value)
+var affinityHolds = map[string][]models.DataType{
+	"integer": {models.DataTypeInteger, models.DataTypeBoolean},
+	"real":    {models.DataTypeInteger, models.DataTypeFloat, models.DataTypeBoolean},
+	"numeric": {models.DataTypeInteger, models.DataTypeFloat, models.DataTypeBoolean},
+	"text":    nil,
+	"blob":    nil,
+}
+
+// PlanChanges - compare analyzed table with existing table from pragma_table_info
+func (r *tableRepository) PlanChanges(ctx context.Context, table models.Table) ([]models.SchemaChange, error) {
+	const op = "sqlite.table.PlanChanges"
+
+	if err := checkSchema(table.Schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := columns(ctx, sqlstore.Conn(ctx, r.db), table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("%s: %s: %w", op, table.QualifiedName(), domain.ErrTableNotFound)
+	}
+
+	var changes []models.SchemaChange
+	for _, col := range table.Columns {
+		current, ok := sqlstore.FindColumn(existing, col.Name)
+		if !ok {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeAddColumn,
+				Column: col.Name,
+				To:     strings.ToLower(lite.ColumnType(col.Type)),
+			})
+			continue
+		}
+
+		if to := widenedType(current.Type, col); to != "" {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeWidenType,
+				Column: col.Name,
+				From:   current.Type,
+				To:     to,
+			})
+			continue
+		}
+
+		if affinity(current.Type) != "text" {
+			continue
+		}
+
+		values, ok, err := enumCheck(ctx, sqlstore.Conn(ctx, r.db), table.Name, col.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if ok && !allowsValues(values, col) {
+			changes = append(changes, models.SchemaChange{
+				Kind:   models.SchemaChangeDropCheck,
+				Column: col.Name,
+				From:   current.Type,
+				To:     current.Type,
+			})
+		}
+	}
+
+	if table.Lineage != nil {
+		changes = append(changes, lineage.Missing(existing)...)
+	}
+
+	return changes, nil
+}
+
+// ApplyChanges - add columns, widen types and drop enum CHECK restrictions in one transaction,
+// SQLite can not change column type or drop restriction, so widening and dropping rebuild the table
+func (r *tableRepository) ApplyChanges(ctx context.Context, table models.Table, changes []models.SchemaChange) error {
+	const op = "sqlite.table.ApplyChanges"
+	log := r.log.With("op", op)
+
+	if err := checkSchema(table.Schema); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	quotedTableName := quoteIdentifier(table.Name)
+	widened := make(map[string]string)
+	var rebuild bool
+
+	var sb strings.Builder
+	for _, c := range changes {
+		// c.To is one of dialect or widening path types, not user input
+		switch c.Kind {
+		case models.SchemaChangeAddColumn:
+			fmt.Fprintf(&sb, "ALTER TABLE %s ADD COLUMN %s %s;", quotedTableName, quoteIdentifier(c.Column), strings.ToUpper(c.To))
+		case models.SchemaChangeWidenType:
+			widened[c.Column] = strings.ToUpper(c.To)
+			rebuild = true
+		case models.SchemaChangeDropCheck:
+			// restrictions are not copied by rebuild
+			rebuild = true
+		default:
+			return fmt.Errorf("%s: unknown change %q", op, c.Kind)
+		}
+	}
+
+	if sb.Len() > 0 {
+		log.Debug("ALTER query is ready", "query", sb.String())
+
+		if _, err := tx.ExecContext(ctx, sb.String()); err != nil {
+			return fmt.Errorf("%s: failed to alter table %s: %w", op, table.QualifiedName(), err)
+		}
+	}
+
+	if rebuild {
+		query, err := rebuildQuery(ctx, tx, table.Name, widened)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Debug("rebuild query is ready", "query", query)
+
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("%s: failed to rebuild table %s: %w", op, table.QualifiedName(), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// rebuildQuery - return statements which copy table to new table with widened column types and replace it
+// (CHECK restrictions of enum columns are not copied)
+func rebuildQuery(ctx context.Context, q sqlstore.Querier, name string, widened map[string]string) (string, error) {
+	existing, err := columns(ctx, q, name)
+	if err != nil {
+		return "", err
+	}
+
+	var strict bool
+	if err := q.QueryRowContext(ctx, `SELECT strict FROM pragma_table_list(?);`, name).Scan(&strict); err != nil {
+		return "", fmt.Errorf("failed to check table %s: %w", name, err)
+	}
+
+	definitions := make([]string, len(existing))
+	values := make([]string, len(existing))
+	for i, col := range existing {
+		quotedColumn := quoteIdentifier(col.Name)
+		typ, ok := widened[col.Name]
+		if !ok {
+			definitions[i] = quotedColumn + " " + strings.ToUpper(col.Type)
+			values[i] = quotedColumn
+			continue
+		}
+		definitions[i] = quotedColumn + " " + typ
+		values[i] = fmt.Sprintf("CAST(%s AS %s)", quotedColumn, typ)
+	}
+
+	tmpName := quoteIdentifier(name + "_rebuild")
+	quotedTableName := quoteIdentifier(name)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "DROP TABLE IF EXISTS %s;", tmpName)
+	fmt.Fprintf(&sb, "CREATE TABLE %s (%s)", tmpName, strings.Join(definitions, ", "))
+	if strict {
+		sb.WriteString(" STRICT")
+	}
+	sb.WriteString(";")
+	fmt.Fprintf(&sb, "INSERT INTO %s SELECT %s FROM %s;", tmpName, strings.Join(values, ", "), quotedTableName)
+	fmt.Fprintf(&sb, "DROP TABLE %s;", quotedTableName)
+	fmt.Fprintf(&sb, "ALTER TABLE %s RENAME TO %s;", tmpName, quotedTableName)
+
+	// index is dropped with old table
+	if _, ok := sqlstore.FindColumn(existing, lineage[0].Name); ok {
+		sb.WriteString(lineageIndexQuery(name))
+	}
+
+	return sb.String(), nil
+}
+
+// enumCheck - return values of CHECK restriction "col IN (...)" of column from table definition
+func enumCheck(ctx context.Context, q sqlstore.Querier, name, column string) ([]string, bool, error) {
+	var definition string
+	err := q.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?;`, name).Scan(&definition)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get definition of %s: %w", name, err)
+	}
+
+	check := regexp.MustCompile(`CHECK \(` + regexp.QuoteMeta(quoteIdentifier(column)) + ` IN \(((?:'(?:[^']|'')*'(?:, )?)*)\)\)`)
+	m := check.FindStringSubmatch(definition)
+	if m == nil {
+		return nil, false, nil
+	}
+
+	var values []string
+	for _, v := range enumCheckValue.FindAllStringSubmatch(m[1], -1) {
+		values = append(values, strings.ReplaceAll(v[1], "''", "'"))
+	}
+	return values, true, nil
+}
+
+// enumCheckValue - string literal of CHECK restriction
+var enumCheckValue = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// allowsValues - check that enum CHECK restriction with values allows all values of col
+func allowsValues(values []string, col models.Column) bool {
+	// column of file has only empty values
+	if col.Type == models.DataTypeUnknown || col.Profile.NullCount > 0 && col.Profile.DistinctCount == 0 {
+		return true
+	}
+	if len(col.EnumValues) == 0 {
+		return false
+	}
+	for _, v := range col.EnumValues {
+		if !slices.Contains(values, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// affinity - return type affinity of declared column type (rules of SQLite "Datatypes" 3.1)
+func affinity(dbType string) string {
+	t := strings.ToLower(dbType)
+	switch {
+	case strings.Contains(t, "int"):
+		return "integer"
+	case strings.Contains(t, "char"), strings.Contains(t, "clob"), strings.Contains(t, "text"):
+		return "text"
+	case t == "", t == "any", strings.Contains(t, "blob"):
+		return "blob"
+	case strings.Contains(t, "real"), strings.Contains(t, "floa"), strings.Contains(t, "doub"):
+		return "real"
+	default:
+		return "numeric"
+	}
+}
+
+// widenedType - return type to which column of dbType must be widened to hold values of col
+// ("" - no change needed)
+func widenedType(dbType string, col models.Column) string {
+	// column of file has only empty values
+	if col.Type == models.DataTypeUnknown || col.Profile.NullCount > 0 && col.Profile.DistinctCount == 0 {
+		return ""
+	}
+
+	holds := affinityHolds[affinity(dbType)]
+	if holds == nil || slices.Contains(holds, col.Type) {
+		return ""
+	}
+
+	if affinity(dbType) == "integer" && col.Type == models.DataTypeFloat {
+		return widenReal
+	}
+
+	return widenText
+}
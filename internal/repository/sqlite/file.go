@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+type fileRepository struct {
+	log *slog.Logger
+}
+
+// NewFileRepository - constructor for DatabaseFileRepository (works without DB of service)
+func NewFileRepository(log *slog.Logger) domain.DatabaseFileRepository {
+	return &fileRepository{log: log}
+}
+
+// WriteSQLite - build SQLite database in memory with tables of this repository and copy it to w as one file
+func (r *fileRepository) WriteSQLite(ctx context.Context, w io.Writer, tables []models.TableData) error {
+	const op = "sqlite.file.WriteSQLite"
+	log := r.log.With("op", op)
+
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer db.Close()
+
+	repo := newTableRepository(db, r.log)
+
+	for _, t := range tables {
+		// file is one database, schema of upload is not kept
+		table := t.Table
+		table.Schema = ""
+
+		if err := repo.Create(ctx, table); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := repo.SaveData(ctx, table, t.Rows); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := repo.SaveRejects(ctx, table, 0, true, t.Rejects); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// VACUUM INTO writes compact file without journal, it needs path of new (empty) file
+	tmp, err := os.CreateTemp("", "sqlconv-*.sqlite")
+	if err != nil {
+		return fmt.Errorf("%s: failed to create temp file: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?;`, tmp.Name()); err != nil {
+		return fmt.Errorf("%s: failed to write database file: %w", op, err)
+	}
+
+	n, err := io.Copy(w, tmp)
+	if err != nil {
+		return fmt.Errorf("%s: failed to copy database file: %w", op, err)
+	}
+
+	log.Debug("database file is written", "tables", len(tables), "bytes", n)
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/schemajson"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+const selectImportsQuery = `SELECT id, filename, table_name, checksum, size_bytes, rows_count, columns_count,
+rejected_count, schema, duration_ms, status, error, COALESCE(idempotency_key, ''), created_at FROM imports`
+
+type importRepository struct {
+	db   *sql.DB
+	keys keyLocks
+	log  *slog.Logger
+}
+
+func newImportRepository(db *sql.DB, log *slog.Logger) *importRepository {
+	return &importRepository{db: db, keys: keyLocks{locks: make(map[string]*keyLock)}, log: log}
+}
+
+// keyLocks - locks of idempotency keys in process (SQLite has no advisory locks, DB file has one writer process)
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock - lock of one key, removed when nobody holds or waits for it
+type keyLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// lock - wait for lock of key, return its unlock
+func (l *keyLocks) lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+
+	select {
+	case kl.ch <- struct{}{}:
+		return func() {
+			<-kl.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// Create - save import record in DB
+func (r *importRepository) Create(ctx context.Context, imp models.Import) (int64, error) {
+	const op = "sqlite.imports.Create"
+
+	schema, err := json.Marshal(schemajson.NewTableSchema(imp.Schema))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to marshal schema: %w", op, err)
+	}
+
+	columns := []string{"filename", "table_name", "checksum", "size_bytes", "rows_count", "columns_count",
+		"rejected_count", "schema", "duration_ms", "status", "error"}
+	args := []any{
+		imp.Filename, imp.TableName, imp.Checksum, imp.Size, imp.Rows, imp.Columns,
+		imp.Rejected, string(schema), imp.Duration.Milliseconds(), string(imp.Status), imp.Error,
+	}
+
+	if imp.IdempotencyKey != "" {
+		columns = append(columns, "idempotency_key")
+		args = append(args, imp.IdempotencyKey)
+	}
+
+	// id is reserved by NextID
+	if imp.ID != 0 {
+		columns = append(columns, "id")
+		args = append(args, imp.ID)
+	}
+
+	query := fmt.Sprintf("INSERT INTO imports (%s) VALUES (%s) RETURNING id;",
+		strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", "))
+
+	var id int64
+	err = sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to save import of %s: %w", op, imp.Filename, err)
+	}
+
+	return id, nil
+}
+
+// NextID - reserve id of import record from sqlite_sequence of imports
+func (r *importRepository) NextID(ctx context.Context) (int64, error) {
+	const op = "sqlite.imports.NextID"
+	log := r.log.With("op", op)
+
+	tx, err := sqlstore.BeginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	// sequence row appears on first insert
+	initQuery := `INSERT INTO sqlite_sequence (name, seq)
+SELECT 'imports', 0 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'imports');`
+	if _, err := tx.ExecContext(ctx, initQuery); err != nil {
+		return 0, fmt.Errorf("%s: failed to init import sequence: %w", op, err)
+	}
+
+	var id int64
+	query := `UPDATE sqlite_sequence SET seq = seq + 1 WHERE name = 'imports' RETURNING seq;`
+	if err := tx.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: failed to reserve import id: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// LockKey - run fn holding lock of idempotency key
+func (r *importRepository) LockKey(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	const op = "sqlite.imports.LockKey"
+
+	unlock, err := r.keys.lock(ctx, key)
+	if err != nil {
+		return fmt.Errorf("%s: failed to lock key %q: %w", op, key, err)
+	}
+	defer unlock()
+
+	return fn(ctx)
+}
+
+// List - get import records from DB (newest first)
+func (r *importRepository) List(ctx context.Context, limit, offset int) ([]models.Import, error) {
+	const op = "sqlite.imports.List"
+
+	rows, err := sqlstore.Conn(ctx, r.db).QueryContext(ctx, selectImportsQuery+" ORDER BY id DESC LIMIT ? OFFSET ?;", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list imports: %w", op, err)
+	}
+	defer rows.Close()
+
+	imports := make([]models.Import, 0, limit)
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		imports = append(imports, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read imports: %w", op, err)
+	}
+
+	return imports, nil
+}
+
+// Get - get import record from DB
+func (r *importRepository) Get(ctx context.Context, id int64) (models.Import, error) {
+	const op = "sqlite.imports.Get"
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, selectImportsQuery+" WHERE id = ?;", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %d: %w", op, id, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+// FindByIdempotencyKey - get latest successful import with idempotency key made after since
+func (r *importRepository) FindByIdempotencyKey(ctx context.Context, key string, since time.Time) (models.Import, error) {
+	const op = "sqlite.imports.FindByIdempotencyKey"
+
+	query := selectImportsQuery + ` WHERE idempotency_key = ? AND status = 'success' AND created_at >= ?
+ORDER BY id DESC LIMIT 1;`
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, key, timestamp(since)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %w", op, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+// FindByChecksum - get latest successful import of file with checksum to table made after since
+func (r *importRepository) FindByChecksum(ctx context.Context, tableName, checksum string, since time.Time) (models.Import, error) {
+	const op = "sqlite.imports.FindByChecksum"
+
+	query := selectImportsQuery + ` WHERE table_name = ? AND checksum = ? AND status = 'success' AND created_at >= ?
+ORDER BY id DESC LIMIT 1;`
+
+	imp, err := scanImport(sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, query, tableName, checksum, timestamp(since)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Import{}, fmt.Errorf("%s: %w", op, domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanImport(row rowScanner) (models.Import, error) {
+	var (
+		imp        models.Import
+		schema     []byte
+		durationMs int64
+		status     string
+	)
+
+	err := row.Scan(&imp.ID, &imp.Filename, &imp.TableName, &imp.Checksum, &imp.Size, &imp.Rows, &imp.Columns,
+		&imp.Rejected, &schema, &durationMs, &status, &imp.Error, &imp.IdempotencyKey, &imp.CreatedAt)
+	if err != nil {
+		return models.Import{}, fmt.Errorf("failed to scan import: %w", err)
+	}
+
+	imp.Duration = time.Duration(durationMs) * time.Millisecond
+	imp.Status = models.ImportStatus(status)
+
+	if len(schema) > 0 {
+		var s schemajson.TableSchema
+		if err := json.Unmarshal(schema, &s); err != nil {
+			return models.Import{}, fmt.Errorf("failed to unmarshal schema: %w", err)
+		}
+		imp.Schema = s.ToModel()
+	}
+
+	return imp, nil
+}
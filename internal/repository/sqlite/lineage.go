@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// lineage - lineage columns of SQLite table
+var lineage = sqlstore.Lineage{
+	{Name: "_import_id", Type: "INTEGER", Value: func(l *models.Lineage, _ int) any { return l.ImportID }},
+	{Name: "_source_file", Type: "TEXT", Value: func(l *models.Lineage, _ int) any { return l.SourceFile }},
+	{Name: "_source_sheet", Type: "TEXT", Value: func(l *models.Lineage, _ int) any { return sqlstore.NullString(l.SourceSheet) }},
+	{Name: "_source_row_number", Type: "INTEGER", Value: func(l *models.Lineage, i int) any { return l.RowNumber(i) }},
+	{Name: "_loaded_at", Type: "TEXT", Value: func(l *models.Lineage, _ int) any { return timestamp(l.LoadedAt) }},
+}
+
+// lineageIndexQuery - return statement which creates index of _import_id
+func lineageIndexQuery(table string) string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
+		quoteIdentifier(table+"_import_id_idx"), quoteIdentifier(table), quoteIdentifier(lineage[0].Name))
+}
+
+// timestamp - return time in format of CURRENT_TIMESTAMP (UTC), comparable as text
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
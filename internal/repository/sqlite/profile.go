@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/schemajson"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+type profileRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func newProfileRepository(db *sql.DB, log *slog.Logger) *profileRepository {
+	return &profileRepository{db: db, log: log}
+}
+
+// Save - save table schema with column profiles in DB
+func (r *profileRepository) Save(ctx context.Context, table models.Table) error {
+	const op = "sqlite.profile.Save"
+
+	profile, err := json.Marshal(schemajson.NewTableSchema(table))
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal profile: %w", op, err)
+	}
+
+	query := `INSERT INTO table_profiles (table_name, profile, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (table_name) DO UPDATE SET profile = excluded.profile, updated_at = excluded.updated_at;`
+
+	// key is "schema.name" (plain name for default schema)
+	if _, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query, table.QualifiedName(), string(profile)); err != nil {
+		return fmt.Errorf("%s: failed to save profile of %s: %w", op, table.QualifiedName(), err)
+	}
+
+	return nil
+}
+
+// Get - get table schema with column profiles from DB
+func (r *profileRepository) Get(ctx context.Context, tableName string) (models.Table, error) {
+	const op = "sqlite.profile.Get"
+
+	var profile []byte
+	err := sqlstore.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT profile FROM table_profiles WHERE table_name = ?;`, tableName).Scan(&profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Table{}, fmt.Errorf("%s: %s: %w", op, tableName, domain.ErrTableNotFound)
+	}
+	if err != nil {
+		return models.Table{}, fmt.Errorf("%s: failed to get profile of %s: %w", op, tableName, err)
+	}
+
+	var schema schemajson.TableSchema
+	if err := json.Unmarshal(profile, &schema); err != nil {
+		return models.Table{}, fmt.Errorf("%s: failed to unmarshal profile: %w", op, err)
+	}
+
+	return schema.ToModel(), nil
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/repository/script"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// Repository - main repository struct (SQLite, embedded DB in one file)
+type repository struct {
+	table   domain.TableRepository
+	profile domain.ProfileRepository
+	imports domain.ImportRepository
+	script  domain.ScriptRepository
+	file    domain.DatabaseFileRepository
+	db      *sql.DB
+	log     *slog.Logger
+}
+
+// NewRepository - constructor for Repository
+func NewRepository(db *sql.DB, log *slog.Logger) *repository {
+	return &repository{
+		table:   newTableRepository(db, log),
+		profile: newProfileRepository(db, log),
+		imports: newImportRepository(db, log),
+		script:  script.NewRepository(log),
+		file:    NewFileRepository(log),
+		db:      db,
+		log:     log,
+	}
+}
+
+// Table - return TableRepository
+func (r *repository) Table() domain.TableRepository {
+	return r.table
+}
+
+// Profile - return ProfileRepository
+func (r *repository) Profile() domain.ProfileRepository {
+	return r.profile
+}
+
+// Import - return ImportRepository
+func (r *repository) Import() domain.ImportRepository {
+	return r.imports
+}
+
+// Script - return ScriptRepository (works without DB)
+func (r *repository) Script() domain.ScriptRepository {
+	return r.script
+}
+
+// DatabaseFile - return DatabaseFileRepository (works without DB)
+func (r *repository) DatabaseFile() domain.DatabaseFileRepository {
+	return r.file
+}
+
+// Atomic - run fn in one transaction, all repository calls with ctx of fn use it
+// (nested call is part of outer transaction)
+func (r *repository) Atomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	return sqlstore.Atomic(ctx, r.db, r.log, fn)
+}
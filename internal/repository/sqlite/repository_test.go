@@ -0,0 +1,284 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/database/migrations"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlite"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+// newTestRepository - repository on in-memory DB with applied migrations
+func newTestRepository(t *testing.T) (domain.Repository, *sql.DB) {
+	t.Helper()
+
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	require.NoError(t, database.NewSQLiteMigrator(db, migrations.SQLite(), log).Up(context.Background()))
+
+	return sqlite.NewRepository(db, log), db
+}
+
+func usersTable() models.Table {
+	return models.Table{
+		Name:     "users",
+		EnumMode: models.EnumModeCheck,
+		Columns: []models.Column{
+			{Name: "id", Type: models.DataTypeInteger},
+			{Name: "name", Type: models.DataTypeString},
+			{Name: "active", Type: models.DataTypeBoolean},
+			{Name: "status", Type: models.DataTypeString, EnumValues: []string{"new", "vip"}},
+		},
+	}
+}
+
+func TestSQLite_CreateSaveRows(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	table := usersTable()
+
+	require.NoError(t, repo.Table().Create(ctx, table))
+	require.NoError(t, repo.Table().Register(ctx, table.Name, 0))
+	require.NoError(t, repo.Table().SaveData(ctx, table, [][]string{
+		{"1", "John", "true", "new"},
+		{"2", "Jane", "false", "vip"},
+		{"3", "", "", ""},
+	}))
+
+	page, err := repo.Table().Rows(ctx, "users", models.RowsQuery{Columns: []string{"id", "name", "active"}, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{int64(1), "John", int64(1)}, {int64(2), "Jane", int64(0)}}, page.Rows)
+	assert.Equal(t, "2", page.NextCursor)
+
+	page, err = repo.Table().Rows(ctx, "main.users", models.RowsQuery{Limit: 2, After: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{int64(3), "", nil, nil}}, page.Rows)
+	assert.Empty(t, page.NextCursor)
+
+	info, err := repo.Table().Describe(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, "main", info.Schema)
+	assert.Equal(t, int64(3), info.Rows)
+	assert.Positive(t, info.SizeBytes)
+	assert.Equal(t, []models.ColumnInfo{
+		{Name: "id", Type: "integer"},
+		{Name: "name", Type: "text"},
+		{Name: "active", Type: "integer"},
+		{Name: "status", Type: "text"},
+	}, info.Columns)
+
+	_, err = repo.Table().Rows(ctx, "users", models.RowsQuery{Limit: 1, After: "(0,1)"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+	err = repo.Table().Create(ctx, models.Table{Name: "sales", Schema: "finance"})
+	assert.ErrorIs(t, err, domain.ErrSchemaNotAllowed)
+
+	_, err = repo.Table().Export(ctx, "sqlite_master", nil)
+	assert.ErrorIs(t, err, domain.ErrTableNotFound)
+}
+
+func TestSQLite_SaveDataTolerant(t *testing.T) {
+	repo, db := newTestRepository(t)
+	ctx := context.Background()
+	table := usersTable()
+
+	require.NoError(t, repo.Table().Create(ctx, table))
+
+	rejects, err := repo.Table().SaveDataTolerant(ctx, table, [][]string{
+		{"1", "John", "true", "new"},
+		{"abc", "Bad", "false", "new"},
+		{"3", "Jane", "false", "gold"},
+	}, 5)
+	require.NoError(t, err)
+	require.Len(t, rejects, 2)
+	assert.Equal(t, 2, rejects[0].Row)
+	assert.Equal(t, "id", rejects[0].Column)
+	assert.Equal(t, 3, rejects[1].Row)
+	assert.Equal(t, "status", rejects[1].Column)
+
+	require.NoError(t, repo.Table().SaveRejects(ctx, table, 1, true, rejects))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM users_rejects;`).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// append keeps rejects of earlier imports, replace recreates table
+	require.NoError(t, repo.Table().SaveRejects(ctx, table, 2, false, rejects[:1]))
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM users_rejects WHERE import_id = 1;`).Scan(&count))
+	assert.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM users_rejects WHERE import_id = 2;`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, repo.Table().SaveRejects(ctx, table, 3, true, rejects[:1]))
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM users_rejects;`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	_, err = repo.Table().SaveDataTolerant(ctx, table, [][]string{{"x", "", "", ""}, {"y", "", "", ""}}, 1)
+	assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+}
+
+func TestSQLite_PlanAndApplyChanges(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	table := usersTable()
+	table.Lineage = &models.Lineage{ImportID: 7, SourceFile: "users.csv", LoadedAt: time.Now()}
+
+	require.NoError(t, repo.Table().Create(ctx, table))
+	require.NoError(t, repo.Table().Register(ctx, table.Name, 7))
+	require.NoError(t, repo.Table().SaveData(ctx, table, [][]string{{"1", "John", "true", "new"}}))
+
+	incoming := models.Table{
+		Name: "users",
+		Columns: []models.Column{
+			{Name: "id", Type: models.DataTypeFloat},
+			{Name: "name", Type: models.DataTypeInteger},
+			{Name: "active", Type: models.DataTypeString},
+			{Name: "email", Type: models.DataTypeString},
+		},
+	}
+
+	changes, err := repo.Table().PlanChanges(ctx, incoming)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SchemaChange{
+		{Kind: models.SchemaChangeWidenType, Column: "id", From: "integer", To: "real"},
+		{Kind: models.SchemaChangeWidenType, Column: "active", From: "integer", To: "text"},
+		{Kind: models.SchemaChangeAddColumn, Column: "email", To: "text"},
+	}, changes)
+
+	require.NoError(t, repo.Table().ApplyChanges(ctx, incoming, changes))
+
+	info, err := repo.Table().Describe(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, models.ColumnInfo{Name: "id", Type: "real"}, info.Columns[0])
+	assert.Equal(t, models.ColumnInfo{Name: "active", Type: "text"}, info.Columns[2])
+	assert.Equal(t, models.ColumnInfo{Name: "email", Type: "text"}, info.Columns[len(info.Columns)-1])
+
+	page, err := repo.Table().Rows(ctx, "users", models.RowsQuery{
+		Columns: []string{"id", "active", "_source_file"},
+		Filters: []models.Filter{{Column: "_import_id", Value: "7"}},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{1.0, "1", "users.csv"}}, page.Rows)
+
+	changes, err = repo.Table().PlanChanges(ctx, incoming)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestSQLite_AppendNewEnumValue(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	table := usersTable()
+
+	require.NoError(t, repo.Table().Create(ctx, table))
+	require.NoError(t, repo.Table().SaveData(ctx, table, [][]string{{"1", "John", "true", "new"}}))
+
+	changes, err := repo.Table().PlanChanges(ctx, table)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	incoming := usersTable()
+	incoming.Columns[3].EnumValues = []string{"gold", "new"}
+
+	changes, err = repo.Table().PlanChanges(ctx, incoming)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SchemaChange{
+		{Kind: models.SchemaChangeDropCheck, Column: "status", From: "text", To: "text"},
+	}, changes)
+
+	require.NoError(t, repo.Table().ApplyChanges(ctx, incoming, changes))
+	require.NoError(t, repo.Table().SaveData(ctx, incoming, [][]string{{"2", "Jane", "false", "gold"}}))
+
+	changes, err = repo.Table().PlanChanges(ctx, incoming)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestSQLite_RenameDrop(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	table := usersTable()
+
+	require.NoError(t, repo.Table().Create(ctx, table))
+	require.NoError(t, repo.Table().Register(ctx, table.Name, 0))
+	require.NoError(t, repo.Profile().Save(ctx, table))
+	require.NoError(t, repo.Table().Create(ctx, models.Table{Name: "orders", Columns: table.Columns[:1]}))
+
+	assert.ErrorIs(t, repo.Table().Rename(ctx, "users", "orders"), domain.ErrTableExists)
+	require.NoError(t, repo.Table().Rename(ctx, "users", "clients"))
+
+	profile, err := repo.Profile().Get(ctx, "clients")
+	require.NoError(t, err)
+	assert.Len(t, profile.Columns, 4)
+
+	tables, err := repo.Table().List(ctx, models.TableFilter{Name: "CLI", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	assert.Equal(t, "clients", tables[0].Name)
+
+	require.NoError(t, repo.Table().Drop(ctx, "clients"))
+
+	// name of dropped table is free, name of existing one is not taken over
+	require.NoError(t, repo.Table().CreateNew(ctx, models.Table{Name: "clients", Columns: table.Columns[:1]}))
+	assert.ErrorIs(t, repo.Table().CreateNew(ctx, models.Table{Name: "orders", Columns: table.Columns[:1]}), domain.ErrTableExists)
+
+	// orders is not created by service
+	assert.ErrorIs(t, repo.Table().Drop(ctx, "orders"), domain.ErrTableNotFound)
+}
+
+func TestSQLite_Imports(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+
+	id, err := repo.Import().NextID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	imp := models.Import{
+		ID:             id,
+		Filename:       "users.csv",
+		TableName:      "users",
+		Checksum:       "abc",
+		Status:         models.ImportStatusSuccess,
+		Duration:       1500 * time.Millisecond,
+		IdempotencyKey: "key-1",
+		Schema:         usersTable(),
+	}
+	_, err = repo.Import().Create(ctx, imp)
+	require.NoError(t, err)
+
+	// id without reservation continues sequence
+	next, err := repo.Import().Create(ctx, models.Import{Filename: "b.csv", TableName: "b", Status: models.ImportStatusFailed})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), next)
+
+	got, err := repo.Import().FindByChecksum(ctx, "users", "abc", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, id, got.ID)
+	assert.Equal(t, 1500*time.Millisecond, got.Duration)
+	assert.Len(t, got.Schema.Columns, 4)
+
+	_, err = repo.Import().FindByIdempotencyKey(ctx, "key-1", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, domain.ErrImportNotFound)
+
+	imports, err := repo.Import().List(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, imports, 2)
+	assert.Equal(t, "b.csv", imports[0].Filename)
+
+	_, err = repo.Import().Get(ctx, 42)
+	assert.ErrorIs(t, err, domain.ErrImportNotFound)
+}
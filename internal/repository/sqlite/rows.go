@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+)
+
+// cursorRegexp - rowid used as keyset cursor
+var cursorRegexp = regexp.MustCompile(`^\d+$`)
+
+// Rows - get page of rows ordered by rowid
+func (r *tableRepository) Rows(ctx context.Context, name string, query models.RowsQuery) (models.RowsPage, error) {
+	const op = "sqlite.table.Rows"
+
+	table, err := managedTable(ctx, sqlstore.Conn(ctx, r.db), name)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tableColumns, err := columns(ctx, sqlstore.Conn(ctx, r.db), table)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(tableColumns) == 0 {
+		return models.RowsPage{}, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	selected, err := sqlstore.SelectColumns(tableColumns, query.Columns)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sqlQuery, args, err := buildRowsQuery(quoteIdentifier(table), selected, tableColumns, query)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page, err := sqlstore.QueryPage(ctx, sqlstore.Conn(ctx, r.db), sqlQuery, args, selected, query.Limit)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	return page, nil
+}
+
+// buildRowsQuery - build SELECT with filters and pagination, first selected value is rowid
+func buildRowsQuery(quotedTableName string, selected, tableColumns []models.ColumnInfo, query models.RowsQuery) (string, []any, error) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("SELECT CAST(rowid AS TEXT)")
+	for _, col := range selected {
+		sb.WriteString(", ")
+		sb.WriteString(quoteIdentifier(col.Name))
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(quotedTableName)
+
+	var conditions []string
+	for _, f := range query.Filters {
+		if _, ok := sqlstore.FindColumn(tableColumns, f.Column); !ok {
+			return "", nil, fmt.Errorf("%s: %w", f.Column, domain.ErrColumnNotFound)
+		}
+		args = append(args, f.Value)
+		conditions = append(conditions, fmt.Sprintf("CAST(%s AS TEXT) = ?", quoteIdentifier(f.Column)))
+	}
+
+	if query.After != "" {
+		if !cursorRegexp.MatchString(query.After) {
+			return "", nil, fmt.Errorf("%q: %w", query.After, domain.ErrInvalidCursor)
+		}
+		args = append(args, query.After)
+		conditions = append(conditions, "rowid > CAST(? AS INTEGER)")
+	}
+
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(conditions, " AND "))
+	}
+
+	args = append(args, query.Limit+1, query.Offset)
+	sb.WriteString(" ORDER BY rowid LIMIT ? OFFSET ?;")
+
+	return sb.String(), args, nil
+}
+
+// Export - stream all rows of table (created by any tool) to w
+func (r *tableRepository) Export(ctx context.Context, name string, w domain.RowWriter) (int64, error) {
+	const op = "sqlite.table.Export"
+
+	table, err := splitName(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	// internal tables of SQLite (sqlite_master, sqlite_sequence, ...) are not exported
+	if strings.HasPrefix(strings.ToLower(table), internalTablePrefix) {
+		return 0, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	columns, err := columns(ctx, sqlstore.Conn(ctx, r.db), table)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("%s: %s: %w", op, name, domain.ErrTableNotFound)
+	}
+
+	quotedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = quoteIdentifier(col.Name)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s;", strings.Join(quotedColumns, ", "), quoteIdentifier(table))
+
+	count, err := sqlstore.Export(ctx, sqlstore.Conn(ctx, r.db), query, columns, w)
+	if err != nil {
+		return count, fmt.Errorf("%s: %s: %w", op, name, err)
+	}
+
+	return count, nil
+}
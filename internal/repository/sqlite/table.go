@@ -0,0 +1,241 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// mainSchema - the only schema of SQLite DB
+const mainSchema = "main"
+
+// internalTablePrefix - prefix of internal tables of SQLite
+const internalTablePrefix = "sqlite_"
+
+// lite - SQL syntax of SQLite
+var lite = dialect.SQLite
+
+// errorColumnRegexps - column in SQLite errors of STRICT type check and CHECK constraint
+var errorColumnRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`column \S+\.(\S+)`),
+	regexp.MustCompile(`CHECK constraint failed: (\w+)`),
+}
+
+type tableRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func newTableRepository(db *sql.DB, log *slog.Logger) *tableRepository {
+	return &tableRepository{db: db, log: log}
+}
+
+// Create - create table in DB
+func (r *tableRepository) Create(ctx context.Context, table models.Table) error {
+	const op = "sqlite.table.Create"
+	log := r.log.With("op", op)
+
+	if err := checkSchema(table.Schema); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := buildCreateQuery(table)
+
+	log.Debug("CREATE query is ready", "query", query)
+
+	if _, err := sqlstore.Conn(ctx, r.db).ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: failed to create table %s: %w", op, table.QualifiedName(), err)
+	}
+
+	return nil
+}
+
+// CreateNew - take name by plain CREATE TABLE (fails if name is taken), then create table as usual
+// in the same transaction
+func (r *tableRepository) CreateNew(ctx context.Context, table models.Table) error {
+	const op = "sqlite.table.CreateNew"
+	log := r.log.With("op", op)
+
+	if err := checkSchema(table.Schema); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sqlstore.Atomic(ctx, r.db, r.log, func(ctx context.Context) error {
+		tx, err := sqlstore.BeginTx(ctx, r.db)
+		if err != nil {
+			return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+		}
+		defer func() {
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				log.Debug("rollback failed", slog.Any("err", err))
+			}
+		}()
+
+		// SQLite has no tables without columns, placeholder column is dropped with the table by Create
+		query := fmt.Sprintf("CREATE TABLE %s (_ INTEGER);", quoteIdentifier(table.Name))
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			if strings.Contains(err.Error(), "already exists") {
+				return fmt.Errorf("%s: %s: %w", op, table.Name, domain.ErrTableExists)
+			}
+			return fmt.Errorf("%s: failed to create table %s: %w", op, table.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+		}
+
+		return r.Create(ctx, table)
+	})
+}
+
+// SaveData - save data in DB
+func (r *tableRepository) SaveData(ctx context.Context, table models.Table, data [][]string) error {
+	const op = "sqlite.table.SaveData"
+
+	if err := sqlstore.Insert(ctx, r.db, r.log, buildInsertQuery(table), data, rowArgs(table)); err != nil {
+		if dataError(err) {
+			return fmt.Errorf("%s: %w: %w", op, domain.ErrRowRejected, err)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveDataTolerant - save data in DB, failed rows are rolled back to savepoint and returned
+func (r *tableRepository) SaveDataTolerant(ctx context.Context, table models.Table, data [][]string, maxErrors int) ([]models.RejectedRow, error) {
+	const op = "sqlite.table.SaveDataTolerant"
+
+	rejects, err := sqlstore.InsertTolerant(ctx, r.db, r.log, buildInsertQuery(table), data, rowArgs(table), maxErrors, errorColumn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rejects, nil
+}
+
+// dataError - report whether row is rejected because of its values:
+// constraint violation (STRICT type check, CHECK, NOT NULL, UNIQUE) or datatype mismatch
+func dataError(err error) bool {
+	var liteErr *sqlitedriver.Error
+	if !errors.As(err, &liteErr) {
+		return false
+	}
+	// primary result code is the least significant byte of extended one
+	code := liteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_CONSTRAINT || code == sqlite3.SQLITE_MISMATCH
+}
+
+// errorColumn - return column name from SQLite error if it is known
+func errorColumn(err error) string {
+	for _, re := range errorColumnRegexps {
+		if m := re.FindStringSubmatch(err.Error()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// SaveRejects - save rejected rows of import in <table>_rejects (recreated in replace mode)
+func (r *tableRepository) SaveRejects(ctx context.Context, table models.Table, importID int64, replace bool, rejects []models.RejectedRow) error {
+	const op = "sqlite.table.SaveRejects"
+	log := r.log.With("op", op)
+
+	quotedRejectsName := quoteIdentifier(rejectsTableName(table.Name))
+
+	createQuery := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s "+
+		"(import_id INTEGER, row_number INTEGER, column_name TEXT, value TEXT, reason TEXT, row_data TEXT);",
+		quotedRejectsName)
+	if replace {
+		createQuery = fmt.Sprintf("DROP TABLE IF EXISTS %s;", quotedRejectsName) + createQuery
+	}
+	insertQuery := fmt.Sprintf("INSERT INTO %s (import_id, row_number, column_name, value, reason, row_data) "+
+		"VALUES (?, ?, ?, ?, ?, ?);", quotedRejectsName)
+
+	err := sqlstore.InsertRejects(ctx, r.db, r.log, createQuery, insertQuery, importID, rejects, func(data []byte) any { return string(data) })
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("rejected rows saved", "table", rejectsTableName(table.Name), "count", len(rejects))
+
+	return nil
+}
+
+// rejectsTableName - return name of table for rejected rows
+func rejectsTableName(tableName string) string {
+	return tableName + "_rejects"
+}
+
+// checkSchema - SQLite DB has only main schema
+func checkSchema(schema string) error {
+	if schema != "" && schema != mainSchema {
+		return fmt.Errorf("%s: SQLite has no schemas: %w", schema, domain.ErrSchemaNotAllowed)
+	}
+	return nil
+}
+
+// tableExists - check that table exists in DB
+func tableExists(ctx context.Context, q sqlstore.Querier, name string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?);`
+	if err := q.QueryRowContext(ctx, query, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+func quoteIdentifier(name string) string {
+	return lite.QuoteIdentifier(name)
+}
+
+// rowArgs - return query arguments of saved rows with lineage values
+func rowArgs(table models.Table) sqlstore.RowArgs {
+	return func(row []string, i int) []any {
+		args := make([]any, len(row), len(row)+len(lineage))
+		for j, v := range row {
+			args[j] = toArg(table, j, v)
+		}
+		return lineage.Append(args, table, i)
+	}
+}
+
+// toArg - convert value of column j to query argument (empty typed value --> NULL, booleans --> 1/0),
+// STRICT table converts numeric text to INTEGER/REAL and rejects other text
+func toArg(table models.Table, j int, v string) any {
+	arg := dialect.Arg(table, j, v)
+	if arg == nil || j >= len(table.Columns) || table.Columns[j].Type != models.DataTypeBoolean {
+		return arg
+	}
+	if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+		return b
+	}
+	return arg
+}
+
+// buildCreateQuery - return statements which (re)create table with lineage columns
+func buildCreateQuery(table models.Table) string {
+	stmts := lite.CreateTable(table, lineage.Definitions(table)...)
+
+	// batch of import can be found (and deleted) by _import_id
+	if table.Lineage != nil {
+		stmts = append(stmts, lineageIndexQuery(table.Name))
+	}
+
+	return strings.Join(stmts, "")
+}
+
+func buildInsertQuery(table models.Table) string {
+	return lite.InsertQuery(table, lineage.Definitions(table)...)
+}
@@ -0,0 +1,163 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// RowArgs - return query arguments of i-th saved row (0-based)
+type RowArgs func(row []string, i int) []any
+
+// Insert - insert rows by prepared query in one transaction, any failed row aborts all
+func Insert(ctx context.Context, db *sql.DB, log *slog.Logger, query string, data [][]string, args RowArgs) error {
+	const op = "sqlstore.Insert"
+	log = log.With("op", op)
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	tx, err := BeginTx(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(tx, log)
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for i, row := range data {
+		if _, err := stmt.ExecContext(ctx, args(row, i)...); err != nil {
+			return fmt.Errorf("%s: failed to insert row %d: %w", op, i+1, err)
+		}
+	}
+
+	log.Debug("INSERT query is ready", "query", query)
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// InsertTolerant - insert rows by prepared query, failed rows are rolled back to savepoint and returned
+// (errorColumn - column of DB error if it is known)
+func InsertTolerant(ctx context.Context, db *sql.DB, log *slog.Logger, query string, data [][]string, args RowArgs,
+	maxErrors int, errorColumn func(err error) string) ([]models.RejectedRow, error) {
+	const op = "sqlstore.InsertTolerant"
+	log = log.With("op", op)
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	tx, err := BeginTx(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(tx, log)
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var rejects []models.RejectedRow
+
+	for i, row := range data {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT row_insert;"); err != nil {
+			return nil, fmt.Errorf("%s: failed to create savepoint: %w", op, err)
+		}
+
+		if _, err := stmt.ExecContext(ctx, args(row, i)...); err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT row_insert;"); rbErr != nil {
+				return nil, fmt.Errorf("%s: failed to rollback to savepoint: %w", op, rbErr)
+			}
+
+			rejects = append(rejects, models.RejectedRow{
+				Row:    i + 1,
+				Column: errorColumn(err),
+				Reason: err.Error(),
+				Values: append([]string(nil), row...),
+			})
+			if len(rejects) > maxErrors {
+				return nil, fmt.Errorf("%s: %d rows failed: %w", op, len(rejects), domain.ErrTooManyErrors)
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT row_insert;"); err != nil {
+			return nil, fmt.Errorf("%s: failed to release savepoint: %w", op, err)
+		}
+	}
+
+	log.Debug("INSERT query is ready", "query", query, "failed", len(rejects))
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return rejects, nil
+}
+
+// InsertRejects - run createQuery of rejects table and insert rejected rows of import by insertQuery
+// (import_id, row_number, column_name, value, reason, row_data), rowData - argument of row values as JSON
+// (importID 0 - rows are not related to import, NULL)
+func InsertRejects(ctx context.Context, db *sql.DB, log *slog.Logger, createQuery, insertQuery string,
+	importID int64, rejects []models.RejectedRow, rowData func(data []byte) any) error {
+	const op = "sqlstore.InsertRejects"
+	log = log.With("op", op)
+
+	if len(rejects) == 0 {
+		return nil
+	}
+
+	tx, err := BeginTx(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(tx, log)
+
+	var id any
+	if importID != 0 {
+		id = importID
+	}
+
+	if _, err := tx.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("%s: failed to create rejects table: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, reject := range rejects {
+		data, err := json.Marshal(reject.Values)
+		if err != nil {
+			return fmt.Errorf("%s: failed to marshal row %d: %w", op, reject.Row, err)
+		}
+
+		if _, err := stmt.ExecContext(ctx, id, reject.Row, reject.Column, reject.Value, reject.Reason, rowData(data)); err != nil {
+			return fmt.Errorf("%s: failed to insert reject of row %d: %w", op, reject.Row, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/dialect"
+)
+
+// LineageColumn - column with origin of imported row (Type and Value are specific to backend)
+type LineageColumn struct {
+	Name  string
+	Type  string
+	Value func(l *models.Lineage, i int) any
+}
+
+// Lineage - lineage columns in order of table, after data columns (first one is _import_id)
+type Lineage []LineageColumn
+
+// Definitions - return lineage columns of table (nil without lineage)
+func (l Lineage) Definitions(table models.Table) []dialect.Column {
+	if table.Lineage == nil {
+		return nil
+	}
+	defs := make([]dialect.Column, len(l))
+	for i, col := range l {
+		defs[i] = dialect.Column{Name: col.Name, Type: col.Type}
+	}
+	return defs
+}
+
+// Append - append lineage values of i-th saved row to args
+func (l Lineage) Append(args []any, table models.Table, i int) []any {
+	if table.Lineage == nil {
+		return args
+	}
+	for _, col := range l {
+		args = append(args, col.Value(table.Lineage, i))
+	}
+	return args
+}
+
+// Missing - return add_column changes for lineage columns absent in existing table
+func (l Lineage) Missing(existing []models.ColumnInfo) []models.SchemaChange {
+	var changes []models.SchemaChange
+	for _, col := range l {
+		if _, ok := FindColumn(existing, col.Name); ok {
+			continue
+		}
+		changes = append(changes, models.SchemaChange{
+			Kind:   models.SchemaChangeAddColumn,
+			Column: col.Name,
+			To:     strings.ToLower(col.Type),
+		})
+	}
+	return changes
+}
+
+// NullString - empty string as NULL (CSV has no sheet)
+func NullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
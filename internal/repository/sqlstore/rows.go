@@ -0,0 +1,125 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// QueryPage - run query of page (first selected value is row cursor, limit+1 rows are requested)
+// and return rows of selected columns with cursor of next page
+func QueryPage(ctx context.Context, q Querier, query string, args []any, selected []models.ColumnInfo, limit int) (models.RowsPage, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	page := models.RowsPage{Columns: selected, Rows: make([][]any, 0, limit)}
+	var rowCursor, lastCursor string
+
+	for rows.Next() {
+		values := make([]any, len(selected))
+		dest := make([]any, len(selected)+1)
+		dest[0] = &rowCursor
+		for i := range values {
+			dest[i+1] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return models.RowsPage{}, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// one extra row was requested to know if there is next page
+		if len(page.Rows) == limit {
+			page.NextCursor = lastCursor
+			break
+		}
+
+		textValues(values)
+		page.Rows = append(page.Rows, values)
+		lastCursor = rowCursor
+	}
+	if err := rows.Err(); err != nil {
+		return models.RowsPage{}, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return page, nil
+}
+
+// Export - run query of all columns and stream rows to w one by one (table is not loaded to memory),
+// return number of written rows
+func Export(ctx context.Context, q Querier, query string, columns []models.ColumnInfo, w domain.RowWriter) (int64, error) {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	if err := w.WriteHeader(columns); err != nil {
+		return 0, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, fmt.Errorf("failed to scan row: %w", err)
+		}
+		textValues(values)
+
+		if err := w.WriteRow(values); err != nil {
+			return count, fmt.Errorf("failed to write row %d: %w", count+1, err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// textValues - replace bytes by strings (numeric, uuid, jsonb, inet, interval are returned as text)
+func textValues(values []any) {
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+}
+
+// SelectColumns - return requested columns in requested order (all columns if none requested)
+func SelectColumns(tableColumns []models.ColumnInfo, requested []string) ([]models.ColumnInfo, error) {
+	if len(requested) == 0 {
+		return tableColumns, nil
+	}
+
+	selected := make([]models.ColumnInfo, 0, len(requested))
+	for _, name := range requested {
+		col, ok := FindColumn(tableColumns, name)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, domain.ErrColumnNotFound)
+		}
+		selected = append(selected, col)
+	}
+
+	return selected, nil
+}
+
+// FindColumn - return column by name
+func FindColumn(columns []models.ColumnInfo, name string) (models.ColumnInfo, bool) {
+	for _, col := range columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return models.ColumnInfo{}, false
+}
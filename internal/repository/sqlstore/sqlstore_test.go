@@ -0,0 +1,90 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlstore"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE items (id INTEGER NOT NULL, name TEXT) STRICT;`)
+	require.NoError(t, err)
+
+	return db
+}
+
+func countItems(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM items;`).Scan(&count))
+	return count
+}
+
+func itemArgs(row []string, _ int) []any {
+	args := make([]any, len(row))
+	for i, v := range row {
+		args[i] = v
+	}
+	return args
+}
+
+func TestAtomic(t *testing.T) {
+	db := newTestDB(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	query := `INSERT INTO items (id, name) VALUES (?, ?);`
+
+	t.Run("failed fn rolls back all calls", func(t *testing.T) {
+		err := sqlstore.Atomic(ctx, db, log, func(ctx context.Context) error {
+			require.NoError(t, sqlstore.Insert(ctx, db, log, query, [][]string{{"1", "a"}}, itemArgs))
+			return errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+		assert.Equal(t, 0, countItems(t, db))
+	})
+
+	t.Run("failed call is rolled back to its savepoint", func(t *testing.T) {
+		err := sqlstore.Atomic(ctx, db, log, func(ctx context.Context) error {
+			require.NoError(t, sqlstore.Insert(ctx, db, log, query, [][]string{{"1", "a"}}, itemArgs))
+			assert.Error(t, sqlstore.Insert(ctx, db, log, query, [][]string{{"2", "b"}, {"x", "c"}}, itemArgs))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, countItems(t, db))
+	})
+}
+
+func TestInsertTolerant(t *testing.T) {
+	db := newTestDB(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	query := `INSERT INTO items (id, name) VALUES (?, ?);`
+	column := func(error) string { return "id" }
+
+	rejects, err := sqlstore.InsertTolerant(ctx, db, log, query, [][]string{{"1", "a"}, {"x", "b"}, {"3", "c"}}, itemArgs, 1, column)
+	require.NoError(t, err)
+	require.Len(t, rejects, 1)
+	assert.Equal(t, 2, rejects[0].Row)
+	assert.Equal(t, []string{"x", "b"}, rejects[0].Values)
+	assert.Equal(t, 2, countItems(t, db))
+
+	_, err = sqlstore.InsertTolerant(ctx, db, log, query, [][]string{{"x", "a"}, {"y", "b"}}, itemArgs, 1, column)
+	assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+	assert.Equal(t, 2, countItems(t, db), "transaction is rolled back")
+}
@@ -0,0 +1,137 @@
+// Package sqlstore - parts of SQL repositories shared by backends: transactions, insert and export loops,
+// lineage columns. Backends keep only their SQL.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Querier - methods shared by *sql.DB and *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// atomicKey - context key of transaction started by Atomic
+type atomicKey struct{}
+
+// atomicTx - transaction shared by repository calls inside Atomic
+type atomicTx struct {
+	tx *sql.Tx
+	// savepoints - counter for unique names of nested transactions
+	savepoints atomic.Int64
+}
+
+// Conn - return transaction of Atomic from ctx, otherwise db
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if at, ok := ctx.Value(atomicKey{}).(*atomicTx); ok {
+		return at.tx
+	}
+	return db
+}
+
+// InAtomic - check if ctx is inside transaction of Atomic
+func InAtomic(ctx context.Context) bool {
+	_, ok := ctx.Value(atomicKey{}).(*atomicTx)
+	return ok
+}
+
+// Tx - own transaction of repository method, or savepoint inside transaction of Atomic
+type Tx struct {
+	*sql.Tx
+	// savepoint - name of savepoint ("" - own transaction)
+	savepoint string
+	done      bool
+}
+
+// BeginTx - begin transaction, inside Atomic create savepoint instead
+// (failed method is rolled back, but transaction stays usable)
+func BeginTx(ctx context.Context, db *sql.DB) (*Tx, error) {
+	at, ok := ctx.Value(atomicKey{}).(*atomicTx)
+	if !ok {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &Tx{Tx: tx}, nil
+	}
+
+	savepoint := fmt.Sprintf("repo_tx_%d", at.savepoints.Add(1))
+	if _, err := at.tx.ExecContext(ctx, "SAVEPOINT "+savepoint+";"); err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: at.tx, savepoint: savepoint}, nil
+}
+
+// Commit - commit own transaction or release savepoint
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint + ";")
+	return err
+}
+
+// Rollback - roll back own transaction or roll back to savepoint
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	if _, err := t.Tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint + ";"); err != nil {
+		return err
+	}
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint + ";")
+	return err
+}
+
+// Atomic - run fn in one transaction, all repository calls with ctx of fn use it
+// (nested call is part of outer transaction)
+func Atomic(ctx context.Context, db *sql.DB, log *slog.Logger, fn func(ctx context.Context) error) error {
+	const op = "sqlstore.Atomic"
+	log = log.With("op", op)
+
+	if _, ok := ctx.Value(atomicKey{}).(*atomicTx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+
+	if err := fn(context.WithValue(ctx, atomicKey{}, &atomicTx{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			log.Warn("rollback failed", slog.Any("err", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// rollback - roll back tx of finished method (no-op after commit)
+func rollback(tx *Tx, log *slog.Logger) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		log.Debug("rollback failed", slog.Any("err", err))
+	}
+}
@@ -4,19 +4,42 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/tmozzze/SQL_Converter/internal/config"
 	"github.com/tmozzze/SQL_Converter/internal/domain"
 	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 )
 
 type schemaAnalyzerService struct {
-	log *slog.Logger
+	bools            boolVocabulary
+	enumThreshold    int
+	enumMode         models.EnumMode
+	profileTopK      int
+	profileSamples   int
+	sampleHead       int
+	sampleSize       int
+	tolerancePercent float64
+	namingPolicy     string
+	log              *slog.Logger
 }
 
-func newSchemaAnalyzerService(log *slog.Logger) domain.SchemaAnalyzerService {
-	return &schemaAnalyzerService{log: log}
+func newSchemaAnalyzerService(bools boolVocabulary, cfg config.AnalyzerCfg, log *slog.Logger) domain.SchemaAnalyzerService {
+	return &schemaAnalyzerService{
+		bools:            bools,
+		enumThreshold:    cfg.EnumThreshold,
+		enumMode:         models.EnumMode(cfg.EnumMode),
+		profileTopK:      cfg.ProfileTopK,
+		profileSamples:   cfg.ProfileSamples,
+		sampleHead:       cfg.SampleHead,
+		sampleSize:       cfg.SampleSize,
+		tolerancePercent: cfg.TolerancePercent,
+		namingPolicy:     cfg.NamingPolicy,
+		log:              log,
+	}
 }
 
 // Analyze - analyzing data schema
@@ -34,28 +57,18 @@ func (s *schemaAnalyzerService) Analyze(ctx context.Context, tableName string, d
 	}
 
 	table := models.Table{
-		Name:    tableName,
-		Columns: make([]models.Column, len(headers)),
+		Name:     tableName,
+		Columns:  make([]models.Column, len(headers)),
+		EnumMode: s.enumMode,
 	}
 
-	usedNames := make(map[string]int)
+	namer := newColumnNamer(s.namingPolicy)
 
 	for i, h := range headers {
-		name := strings.TrimSpace(h)
-		if name == "" {
-			name = fmt.Sprintf("col_%d", i+1)
-		}
-
-		if count, exists := usedNames[name]; exists {
-			usedNames[name]++
-			name = fmt.Sprintf("%s_%d", name, count)
-		} else {
-			usedNames[name] = 1
-		}
-
 		table.Columns[i] = models.Column{
-			Name: name,
-			Type: models.DataTypeUnknown,
+			Name:   namer.name(h, i),
+			Source: strings.TrimSpace(h),
+			Type:   models.DataTypeUnknown,
 		}
 	}
 
@@ -68,9 +81,21 @@ func (s *schemaAnalyzerService) Analyze(ctx context.Context, tableName string, d
 		return table, nil
 	}
 
-	// analyze data
+	// rows for type detection (all rows or sample)
 	rows := data[1:]
-	for count, row := range rows {
+	sample := s.sampleRows(len(rows))
+	if len(sample) < len(rows) {
+		log.Debug("analyzing sample of rows", "sample", len(sample), "rows", len(rows))
+	}
+
+	// columns holding only 1/0 so far (can be widened from Boolean to Integer)
+	binary := make([]bool, len(table.Columns))
+	for i := range binary {
+		binary[i] = true
+	}
+
+	// analyze data
+	for count, idx := range sample {
 
 		// checking context
 		if count%15 == 0 {
@@ -81,26 +106,202 @@ func (s *schemaAnalyzerService) Analyze(ctx context.Context, tableName string, d
 			}
 		}
 
-		for i, val := range row {
+		for i, val := range rows[idx] {
 			if i >= len(table.Columns) {
 				break
 			}
 			if table.Columns[i].Type == models.DataTypeString {
 				continue
 			}
-			table.Columns[i].Type = s.detectType(val, table.Columns[i].Type)
+
+			prevType := table.Columns[i].Type
+			newType := s.detectType(val, prevType)
+
+			// 1/0 column got another number --> it is numeric, not boolean
+			if prevType == models.DataTypeBoolean && newType == models.DataTypeString && binary[i] {
+				newType = s.detectType(val, models.DataTypeInteger)
+			}
+			if v := strings.TrimSpace(val); v != "" && v != "1" && v != "0" {
+				binary[i] = false
+			}
+
+			// remember value which forced type change
+			if prevType != models.DataTypeUnknown && newType != prevType {
+				table.Columns[i].Downgrades = append(table.Columns[i].Downgrades, models.TypeChange{
+					From:  prevType,
+					To:    newType,
+					Value: val,
+					Row:   idx + 1,
+				})
+			}
+
+			table.Columns[i].Type = newType
 		}
 	}
 
+	// confidence and tolerance
+	if err := s.score(ctx, &table, rows, sample); err != nil {
+		return models.Table{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	for i := range table.Columns {
 		if table.Columns[i].Type == models.DataTypeUnknown {
 			table.Columns[i].Type = models.DataTypeString
 		}
 	}
 
+	// profiling
+	if err := s.profile(ctx, &table, rows); err != nil {
+		return models.Table{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return table, nil
 }
 
+// sampleRows - return indexes of rows for type detection:
+// first sampleHead rows and reservoir sample of sampleSize rows from the rest
+func (s *schemaAnalyzerService) sampleRows(count int) []int {
+	if s.sampleHead <= 0 || count <= s.sampleHead+s.sampleSize {
+		sample := make([]int, count)
+		for i := range sample {
+			sample[i] = i
+		}
+		return sample
+	}
+
+	sample := make([]int, s.sampleHead, s.sampleHead+s.sampleSize)
+	for i := range sample {
+		sample[i] = i
+	}
+
+	// reservoir sampling (algorithm R), seeded by count --> same file gives same schema
+	rnd := rand.New(rand.NewPCG(uint64(count), uint64(s.sampleSize)))
+	reservoir := make([]int, 0, s.sampleSize)
+	for i := s.sampleHead; i < count; i++ {
+		if len(reservoir) < s.sampleSize {
+			reservoir = append(reservoir, i)
+			continue
+		}
+		if j := rnd.IntN(i - s.sampleHead + 1); j < s.sampleSize {
+			reservoir[j] = i
+		}
+	}
+	sort.Ints(reservoir)
+
+	return append(sample, reservoir...)
+}
+
+// score - compute confidence of detected types and keep numeric types within tolerance
+func (s *schemaAnalyzerService) score(ctx context.Context, table *models.Table, rows [][]string, sample []int) error {
+	// type to check values against: detected type or last type before downgrade to string
+	candidates := make([]models.DataType, len(table.Columns))
+	for i, col := range table.Columns {
+		candidates[i] = col.Type
+		if col.Type == models.DataTypeString && len(col.Downgrades) > 0 {
+			candidates[i] = col.Downgrades[len(col.Downgrades)-1].From
+		}
+	}
+
+	filled := make([]int, len(table.Columns))
+	valid := make([]int, len(table.Columns))
+
+	for count, idx := range sample {
+
+		// checking context
+		if count%15 == 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("context canceled: %w", ctx.Err())
+			default:
+			}
+		}
+
+		for i, val := range rows[idx] {
+			if i >= len(table.Columns) {
+				break
+			}
+			val = strings.TrimSpace(val)
+			if val == "" {
+				continue
+			}
+			filled[i]++
+			if isValidValue(s.bools, val, candidates[i]) {
+				valid[i]++
+			}
+		}
+	}
+
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if filled[i] == 0 {
+			col.Confidence = 0
+			continue
+		}
+
+		share := float64(valid[i]) / float64(filled[i])
+
+		if col.Type == models.DataTypeString && candidates[i] != models.DataTypeString {
+			// few invalid values --> keep numeric type, invalid rows go to rejects
+			invalidPercent := (1 - share) * 100
+			if isNumericType(candidates[i]) && invalidPercent < s.tolerancePercent {
+				s.log.Debug("column keeps type within tolerance",
+					"column", col.Name, "type", candidates[i].String(), "invalid_percent", invalidPercent)
+				col.Type = candidates[i]
+				col.Confidence = share
+				continue
+			}
+			// string is confirmed by share of values which are not candidate type
+			col.Confidence = 1 - share
+			continue
+		}
+
+		col.Confidence = share
+	}
+
+	return nil
+}
+
+// profile - collect statistics of columns with detected types
+func (s *schemaAnalyzerService) profile(ctx context.Context, table *models.Table, rows [][]string) error {
+	profilers := make([]*columnProfiler, len(table.Columns))
+	for i, col := range table.Columns {
+		profilers[i] = newColumnProfiler(col.Type, s.profileTopK, s.profileSamples)
+	}
+
+	// low-cardinality values (all rows, so sampling does not miss rare values)
+	enums := newEnumDetector(s.enumThreshold, len(table.Columns))
+
+	for count, row := range rows {
+
+		// checking context
+		if count%15 == 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("context canceled: %w", ctx.Err())
+			default:
+			}
+		}
+
+		for i, p := range profilers {
+			val := ""
+			if i < len(row) {
+				val = row[i]
+			}
+			p.add(val)
+			enums.add(i, val)
+		}
+	}
+
+	for i, p := range profilers {
+		table.Columns[i].Profile = p.result()
+		if table.Columns[i].Type == models.DataTypeString && s.enumMode != models.EnumModeNone {
+			table.Columns[i].EnumValues = enums.values(i)
+		}
+	}
+
+	return nil
+}
+
 func (s *schemaAnalyzerService) detectType(val string, currentType models.DataType) models.DataType {
 	// string
 	if currentType == models.DataTypeString {
@@ -114,8 +315,7 @@ func (s *schemaAnalyzerService) detectType(val string, currentType models.DataTy
 	}
 
 	// boolean
-	lowVal := strings.ToLower(val)
-	isBool := lowVal == "true" || lowVal == "false"
+	_, isBool := s.bools.lookup(val)
 
 	// integer
 	_, errInt := strconv.ParseInt(val, 10, 64)
@@ -137,7 +337,7 @@ func (s *schemaAnalyzerService) detectType(val string, currentType models.DataTy
 		if isFloat {
 			return models.DataTypeFloat
 		}
-		return models.DataTypeString
+		return detectSemanticType(val)
 	// boolean
 	case models.DataTypeBoolean:
 		if isBool {
@@ -161,8 +361,64 @@ func (s *schemaAnalyzerService) detectType(val string, currentType models.DataTy
 			return models.DataTypeFloat
 		}
 		return models.DataTypeString
+
+	// uuid
+	case models.DataTypeUUID:
+		if isUUID(val) {
+			return models.DataTypeUUID
+		}
+		return models.DataTypeString
+
+	// json
+	case models.DataTypeJSON:
+		if isJSON(val) {
+			return models.DataTypeJSON
+		}
+		return models.DataTypeString
+
+	// cidr (network addresses only) --> inet on any host address
+	case models.DataTypeCIDR:
+		if isCIDR(val) {
+			return models.DataTypeCIDR
+		}
+		if isInet(val) {
+			return models.DataTypeInet
+		}
+		return models.DataTypeString
+
+	// inet
+	case models.DataTypeInet:
+		if isInet(val) {
+			return models.DataTypeInet
+		}
+		return models.DataTypeString
+
+	// interval
+	case models.DataTypeInterval:
+		if isInterval(val) {
+			return models.DataTypeInterval
+		}
+		return models.DataTypeString
 	default:
 		return models.DataTypeString
 	}
 
 }
+
+// detectSemanticType - detect types which are not numbers or booleans
+func detectSemanticType(val string) models.DataType {
+	switch {
+	case isUUID(val):
+		return models.DataTypeUUID
+	case isCIDR(val):
+		return models.DataTypeCIDR
+	case isInet(val):
+		return models.DataTypeInet
+	case isInterval(val):
+		return models.DataTypeInterval
+	case isJSON(val):
+		return models.DataTypeJSON
+	default:
+		return models.DataTypeString
+	}
+}
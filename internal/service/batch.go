@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// errStepFailed - stop all-or-nothing batch (reason is in step result)
+var errStepFailed = errors.New("batch step failed")
+
+type batchService struct {
+	repo      domain.Repository
+	processor domain.ProcessorService
+
+	parallelism int
+
+	log *slog.Logger
+}
+
+func newBatchService(repo domain.Repository, processor domain.ProcessorService, cfg config.ImportCfg, log *slog.Logger) domain.BatchService {
+	return &batchService{
+		repo:        repo,
+		processor:   processor,
+		parallelism: max(cfg.BatchParallelism, 1),
+		log:         log,
+	}
+}
+
+// manifestYAML - YAML manifest of batch
+type manifestYAML struct {
+	// Parallelism - 0 - default of config, capped by config
+	Parallelism  int  `yaml:"parallelism"`
+	AllOrNothing bool `yaml:"all_or_nothing"`
+	// Defaults - settings of files which don't set them
+	Defaults stepYAML   `yaml:"defaults"`
+	Files    []stepYAML `yaml:"files"`
+}
+
+// stepYAML - file of YAML manifest
+type stepYAML struct {
+	Name       string   `yaml:"name"`
+	File       string   `yaml:"file"`
+	Table      string   `yaml:"table"`
+	Mode       string   `yaml:"mode"`
+	Schema     string   `yaml:"schema"`
+	OnConflict string   `yaml:"on_conflict"`
+	Strict     *bool    `yaml:"strict"`
+	Lineage    *bool    `yaml:"lineage"`
+	Force      *bool    `yaml:"force"`
+	DependsOn  []string `yaml:"depends_on"`
+}
+
+// ParseManifest - read YAML manifest, apply defaults and validate steps and dependencies
+func (s *batchService) ParseManifest(r io.Reader) (models.BatchManifest, error) {
+	const op = "service.batch.ParseManifest"
+
+	var raw manifestYAML
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&raw); err != nil {
+		return models.BatchManifest{}, fmt.Errorf("%s: %v: %w", op, err, domain.ErrInvalidManifest)
+	}
+
+	if len(raw.Files) == 0 {
+		return models.BatchManifest{}, fmt.Errorf("%s: no files: %w", op, domain.ErrInvalidManifest)
+	}
+	if raw.Parallelism < 0 {
+		return models.BatchManifest{}, fmt.Errorf("%s: negative parallelism: %w", op, domain.ErrInvalidManifest)
+	}
+
+	manifest := models.BatchManifest{
+		Parallelism:  s.parallelism,
+		AllOrNothing: raw.AllOrNothing,
+		Steps:        make([]models.BatchStep, len(raw.Files)),
+	}
+	if raw.Parallelism > 0 {
+		manifest.Parallelism = min(raw.Parallelism, s.parallelism)
+	}
+
+	names := make(map[string]bool, len(raw.Files))
+	for i, f := range raw.Files {
+		step, err := batchStep(f, raw.Defaults)
+		if err != nil {
+			return models.BatchManifest{}, fmt.Errorf("%s: file %d: %v: %w", op, i+1, err, domain.ErrInvalidManifest)
+		}
+		if names[step.Name] {
+			return models.BatchManifest{}, fmt.Errorf("%s: duplicate name %q: %w", op, step.Name, domain.ErrInvalidManifest)
+		}
+		names[step.Name] = true
+		manifest.Steps[i] = step
+	}
+
+	if _, err := batchOrder(manifest.Steps); err != nil {
+		return models.BatchManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return manifest, nil
+}
+
+// FilesManifest - return manifest of independent files with the same options,
+// idempotency key of request becomes key of every file (<key>/<file>).
+// Files are loaded concurrently --> their tables (derived from file names) must differ
+func (s *batchService) FilesManifest(files []string, opts models.UploadOptions, parallelism int, allOrNothing bool) (models.BatchManifest, error) {
+	const op = "service.batch.FilesManifest"
+
+	tables := make(map[string]string, len(files))
+	for _, file := range files {
+		// invalid name fails only its file
+		name, err := requestedTableName(file, opts)
+		if err != nil {
+			continue
+		}
+		if other, ok := tables[name]; ok {
+			return models.BatchManifest{}, fmt.Errorf("%s: files %q and %q are loaded to the same table %q: %w",
+				op, other, file, name, domain.ErrInvalidManifest)
+		}
+		tables[name] = file
+	}
+
+	manifest := models.BatchManifest{
+		Parallelism:  s.parallelism,
+		AllOrNothing: allOrNothing,
+		Steps:        make([]models.BatchStep, len(files)),
+	}
+	if parallelism > 0 {
+		manifest.Parallelism = min(parallelism, s.parallelism)
+	}
+
+	for i, file := range files {
+		stepOpts := opts
+		if opts.IdempotencyKey != "" {
+			stepOpts.IdempotencyKey = opts.IdempotencyKey + "/" + file
+		}
+		manifest.Steps[i] = models.BatchStep{Name: file, File: file, Options: stepOpts}
+	}
+
+	return manifest, nil
+}
+
+// batchStep - return step of file with defaults applied
+func batchStep(f, defaults stepYAML) (models.BatchStep, error) {
+	if f.File == "" {
+		return models.BatchStep{}, errors.New("file is required")
+	}
+
+	step := models.BatchStep{
+		Name: f.Name,
+		File: f.File,
+		Options: models.UploadOptions{
+			Mode:       models.UploadMode(orDefault(f.Mode, defaults.Mode)),
+			Table:      f.Table,
+			Schema:     orDefault(f.Schema, defaults.Schema),
+			OnConflict: models.ConflictPolicy(orDefault(f.OnConflict, defaults.OnConflict)),
+			Strict:     boolOrDefault(f.Strict, defaults.Strict),
+			Lineage:    boolOrDefault(f.Lineage, defaults.Lineage),
+			Force:      boolOrDefault(f.Force, defaults.Force),
+		},
+		DependsOn: f.DependsOn,
+	}
+	if step.Name == "" {
+		step.Name = f.File
+	}
+
+	switch step.Options.Mode {
+	case "", models.UploadModeReplace, models.UploadModeAppend:
+	default:
+		return models.BatchStep{}, fmt.Errorf("invalid mode %q", step.Options.Mode)
+	}
+
+	switch step.Options.OnConflict {
+	case "", models.ConflictOverwrite, models.ConflictSuffix:
+	default:
+		return models.BatchStep{}, fmt.Errorf("invalid on_conflict %q", step.Options.OnConflict)
+	}
+	if step.Options.Mode == models.UploadModeAppend && step.Options.OnConflict == models.ConflictSuffix {
+		return models.BatchStep{}, errors.New("on_conflict suffix can't be used with append mode")
+	}
+
+	return step, nil
+}
+
+// orDefault - return value or default if value is empty
+func orDefault(value, def string) string {
+	if value != "" {
+		return value
+	}
+	return def
+}
+
+// boolOrDefault - return value or default if value is not set
+func boolOrDefault(value, def *bool) bool {
+	if value != nil {
+		return *value
+	}
+	return def != nil && *def
+}
+
+// batchOrder - return indexes of steps in order of dependencies (manifest order where possible)
+func batchOrder(steps []models.BatchStep) ([]int, error) {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		index[step.Name] = i
+	}
+
+	pending := make([]int, len(steps))
+	dependents := make([][]int, len(steps))
+	for i, step := range steps {
+		for _, dep := range step.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("%q depends on unknown %q: %w", step.Name, dep, domain.ErrInvalidManifest)
+			}
+			if j == i {
+				return nil, fmt.Errorf("%q depends on itself: %w", step.Name, domain.ErrInvalidManifest)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	order := make([]int, 0, len(steps))
+	done := make([]bool, len(steps))
+	for len(order) < len(steps) {
+		next := -1
+		for i := range steps {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("dependency cycle: %w", domain.ErrInvalidManifest)
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+
+	return order, nil
+}
+
+// Run - import files of manifest, return consolidated report
+// (failed steps are reported, error is returned only for invalid manifest)
+func (s *batchService) Run(ctx context.Context, manifest models.BatchManifest, open domain.FileOpener) (models.BatchReport, error) {
+	const op = "service.batch.Run"
+	log := s.log.With("op", op)
+
+	order, err := batchOrder(manifest.Steps)
+	if err != nil {
+		return models.BatchReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	started := time.Now()
+
+	var report models.BatchReport
+	if manifest.AllOrNothing {
+		report = s.runAtomic(ctx, manifest.Steps, order, open)
+	} else {
+		report = s.runParallel(ctx, manifest, open)
+	}
+	report.Duration = time.Since(started)
+
+	log.Info("batch finished",
+		slog.String("status", string(report.Status)),
+		slog.Int("files", len(report.Steps)),
+		slog.Bool("all_or_nothing", manifest.AllOrNothing),
+		slog.Duration("duration", report.Duration),
+	)
+
+	return report, nil
+}
+
+// runAtomic - import files one by one in one transaction, first failure rolls back the whole batch
+func (s *batchService) runAtomic(ctx context.Context, steps []models.BatchStep, order []int, open domain.FileOpener) models.BatchReport {
+	results := skippedResults(steps)
+
+	err := s.repo.Atomic(ctx, func(ctx context.Context) error {
+		for _, i := range order {
+			results[i] = s.runStep(ctx, steps[i], open)
+			if results[i].Status != models.BatchStatusSuccess {
+				return errStepFailed
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return models.BatchReport{Status: models.BatchStatusSuccess, Steps: results}
+	}
+
+	for i := range results {
+		if results[i].Status == models.BatchStatusSuccess {
+			results[i].Status = models.BatchStatusRolledBack
+		}
+	}
+
+	report := models.BatchReport{Status: models.BatchStatusFailed, Steps: results}
+	if !errors.Is(err, errStepFailed) {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// runParallel - import files with bounded parallelism, step starts when its dependencies succeeded
+func (s *batchService) runParallel(ctx context.Context, manifest models.BatchManifest, open domain.FileOpener) models.BatchReport {
+	steps := manifest.Steps
+	results := skippedResults(steps)
+
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		index[step.Name] = i
+	}
+
+	done := make([]chan struct{}, len(steps))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, max(manifest.Parallelism, 1))
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+
+			// result of dependency is written before its channel is closed
+			for _, dep := range step.DependsOn {
+				j := index[dep]
+				<-done[j]
+				if results[j].Status != models.BatchStatusSuccess {
+					results[i].Error = fmt.Sprintf("dependency %q did not succeed", dep)
+					return
+				}
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i].Error = ctx.Err().Error()
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i] = s.runStep(ctx, step, open)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Status == models.BatchStatusSuccess {
+			succeeded++
+		}
+	}
+
+	status := models.BatchStatusPartial
+	switch succeeded {
+	case len(results):
+		status = models.BatchStatusSuccess
+	case 0:
+		status = models.BatchStatusFailed
+	}
+
+	return models.BatchReport{Status: status, Steps: results}
+}
+
+// runStep - open file of step and upload it
+func (s *batchService) runStep(ctx context.Context, step models.BatchStep, open domain.FileOpener) models.BatchStepResult {
+	const op = "service.batch.runStep"
+	log := s.log.With("op", op, slog.String("step", step.Name))
+
+	started := time.Now()
+	result := models.BatchStepResult{Name: step.Name, File: step.File, Status: models.BatchStatusFailed}
+
+	file, err := open(step.File)
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(started)
+		log.Warn("failed to open file", slog.Any("err", err))
+		return result
+	}
+	defer file.Close()
+
+	filename := path.Base(filepath.ToSlash(step.File))
+	imported, err := s.processor.UploadFile(ctx, filename, file, strings.ToLower(path.Ext(filename)), step.Options)
+	result.Duration = time.Since(started)
+	if err != nil {
+		result.Error = err.Error()
+		log.Warn("step failed", slog.Any("err", err))
+		return result
+	}
+
+	result.Status = models.BatchStatusSuccess
+	result.Result = imported
+	return result
+}
+
+// skippedResults - initial results of steps (not run)
+func skippedResults(steps []models.BatchStep) []models.BatchStepResult {
+	results := make([]models.BatchStepResult, len(steps))
+	for i, step := range steps {
+		results[i] = models.BatchStepResult{Name: step.Name, File: step.File, Status: models.BatchStatusSkipped}
+	}
+	return results
+}
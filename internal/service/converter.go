@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// boolVocabulary - words recognized as boolean values (lowercase word -> value)
+type boolVocabulary map[string]bool
+
+func newBoolVocabulary(cfg config.AnalyzerCfg) boolVocabulary {
+	vocab := make(boolVocabulary, len(cfg.BoolTrue)+len(cfg.BoolFalse)+2)
+
+	for _, w := range cfg.BoolTrue {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			vocab[w] = true
+		}
+	}
+	for _, w := range cfg.BoolFalse {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			vocab[w] = false
+		}
+	}
+
+	// 1/0 only when enabled, otherwise they clash with Integer
+	if cfg.NumericBool {
+		vocab["1"] = true
+		vocab["0"] = false
+	} else {
+		delete(vocab, "1")
+		delete(vocab, "0")
+	}
+
+	return vocab
+}
+
+// lookup - return boolean value of word and whether it is in vocabulary
+func (v boolVocabulary) lookup(val string) (value bool, ok bool) {
+	value, ok = v[strings.ToLower(strings.TrimSpace(val))]
+	return value, ok
+}
+
+type valueConverter struct {
+	bools boolVocabulary
+}
+
+func newValueConverter(bools boolVocabulary) *valueConverter {
+	return &valueConverter{bools: bools}
+}
+
+// Convert - normalize row values to the form accepted by DB for column types.
+// Rows with values invalid for column type are returned as rejects,
+// numbers - data row numbers (1-based) of converted rows.
+func (c *valueConverter) Convert(table models.Table, data [][]string) (rows [][]string, numbers []int, rejects []models.RejectedRow) {
+	rows = data[:0]
+	numbers = make([]int, 0, len(data))
+
+	for n, row := range data {
+		reject, ok := c.validate(table, row)
+		if !ok {
+			reject.Row = n + 1
+			rejects = append(rejects, reject)
+			continue
+		}
+
+		for i, val := range row {
+			if i >= len(table.Columns) {
+				break
+			}
+			row[i] = c.convertValue(val, table.Columns[i].Type)
+		}
+		rows = append(rows, row)
+		numbers = append(numbers, n+1)
+	}
+
+	return rows, numbers, rejects
+}
+
+// validate - check row values against column types
+func (c *valueConverter) validate(table models.Table, row []string) (models.RejectedRow, bool) {
+	for i, val := range row {
+		if i >= len(table.Columns) {
+			break
+		}
+		col := table.Columns[i]
+		v := strings.TrimSpace(val)
+		if v == "" || isValidValue(c.bools, v, col.Type) {
+			continue
+		}
+		return models.RejectedRow{
+			Column: col.Name,
+			Value:  val,
+			Reason: fmt.Sprintf("invalid %s value", col.Type),
+			Values: append([]string(nil), row...),
+		}, false
+	}
+	return models.RejectedRow{}, true
+}
+
+func (c *valueConverter) convertValue(val string, t models.DataType) string {
+	switch t {
+	case models.DataTypeBoolean:
+		if b, ok := c.bools.lookup(val); ok {
+			if b {
+				return "true"
+			}
+			return "false"
+		}
+	case models.DataTypeInterval:
+		return formatInterval(strings.TrimSpace(val))
+	}
+	return val
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxEnumLabelLen - Postgres limit for enum label (NAMEDATALEN - 1)
+const maxEnumLabelLen = 63
+
+// enumDetector - collect distinct column values while their count is below threshold
+type enumDetector struct {
+	threshold int
+	distinct  []map[string]struct{}
+	filled    []int
+}
+
+func newEnumDetector(threshold int, columns int) *enumDetector {
+	d := &enumDetector{
+		threshold: threshold,
+		distinct:  make([]map[string]struct{}, columns),
+		filled:    make([]int, columns),
+	}
+	if threshold <= 0 {
+		return d
+	}
+	for i := range d.distinct {
+		d.distinct[i] = make(map[string]struct{})
+	}
+	return d
+}
+
+// add - register value of column i
+func (d *enumDetector) add(i int, val string) {
+	if d.distinct[i] == nil {
+		return
+	}
+
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return
+	}
+	d.filled[i]++
+
+	if len(val) > maxEnumLabelLen {
+		d.distinct[i] = nil
+		return
+	}
+
+	d.distinct[i][val] = struct{}{}
+	if len(d.distinct[i]) > d.threshold {
+		d.distinct[i] = nil
+	}
+}
+
+// values - return sorted value set of column i or nil if it is not low-cardinality
+func (d *enumDetector) values(i int) []string {
+	set := d.distinct[i]
+	// every value is unique --> identifiers, not statuses
+	if len(set) == 0 || d.filled[i] <= len(set) {
+		return nil
+	}
+
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// DB types (information_schema.columns.data_type) which need special formatting
+const (
+	dbTypeDate      = "date"
+	dbTypeTimestamp = "timestamp without time zone"
+	dbTypeNumeric   = "numeric"
+)
+
+// formulaPrefixes - first characters which make spreadsheet evaluate cell as formula
+const formulaPrefixes = "=+-@\t\r"
+
+type exportService struct {
+	repo           domain.Repository
+	allowedSchemas []string
+	log            *slog.Logger
+}
+
+func newExportService(repo domain.Repository, allowedSchemas []string, log *slog.Logger) domain.ExportService {
+	return &exportService{repo: repo, allowedSchemas: allowedSchemas, log: log}
+}
+
+// rowWriter - domain.RowWriter which must be closed to finish file (or discarded on error)
+type rowWriter interface {
+	domain.RowWriter
+	Close() error
+	Discard()
+}
+
+// Export - stream table to w as CSV or XLSX
+func (s *exportService) Export(ctx context.Context, tableName string, extension string, w io.Writer) (int64, error) {
+	const op = "service.export.Export"
+	log := s.log.With("op", op)
+
+	// only user tables of allowed schemas can be exported
+	schema, table := models.SplitQualifiedName(tableName)
+	if err := checkSchema(s.allowedSchemas, schema); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if reservedTableName(table) {
+		return 0, fmt.Errorf("%s: %q: %w", op, tableName, domain.ErrInvalidTableName)
+	}
+
+	rw, err := newRowWriter(extension, w)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	count, err := s.repo.Table().Export(ctx, tableName, rw)
+	if err != nil {
+		rw.Discard()
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := rw.Close(); err != nil {
+		return count, fmt.Errorf("%s: failed to finish file: %w", op, err)
+	}
+
+	log.Info("table exported", slog.String("table", tableName), slog.String("format", extension), slog.Int64("rows", count))
+	return count, nil
+}
+
+// WritePage - write page of rows to w as CSV or XLSX
+func (s *exportService) WritePage(page models.RowsPage, extension string, w io.Writer) error {
+	const op = "service.export.WritePage"
+
+	rw, err := newRowWriter(extension, w)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := rw.WriteHeader(page.Columns); err != nil {
+		rw.Discard()
+		return fmt.Errorf("%s: failed to write header: %w", op, err)
+	}
+	for _, row := range page.Rows {
+		if err := rw.WriteRow(row); err != nil {
+			rw.Discard()
+			return fmt.Errorf("%s: failed to write row: %w", op, err)
+		}
+	}
+
+	if err := rw.Close(); err != nil {
+		return fmt.Errorf("%s: failed to finish file: %w", op, err)
+	}
+	return nil
+}
+
+func newRowWriter(extension string, w io.Writer) (rowWriter, error) {
+	switch strings.ToLower(extension) {
+	case domain.ExtCSV:
+		return &csvRowWriter{w: csv.NewWriter(w)}, nil
+	case domain.ExtXLSX:
+		return newXLSXRowWriter(w)
+	default:
+		return nil, fmt.Errorf("%s: %w", extension, domain.ErrUnsupportedExtension)
+	}
+}
+
+// csvRowWriter - write rows as CSV text, escaping text cells which look like formulas
+type csvRowWriter struct {
+	w       *csv.Writer
+	columns []models.ColumnInfo
+	record  []string
+}
+
+func (c *csvRowWriter) WriteHeader(columns []models.ColumnInfo) error {
+	c.columns = columns
+	c.record = make([]string, len(columns))
+	// column names come from headers of uploaded file
+	for i, col := range columns {
+		c.record[i] = escapeFormula(col.Name)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvRowWriter) WriteRow(values []any) error {
+	for i, v := range values {
+		c.record[i] = formatExportValue(v, c.columns[i].Type)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRowWriter) Discard() {}
+
+// formatExportValue - format DB value as CSV text (NULL as empty string)
+func formatExportValue(v any, dbType string) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if dbType == dbTypeNumeric {
+			return val
+		}
+		return escapeFormula(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		switch dbType {
+		case dbTypeDate:
+			return val.Format(time.DateOnly)
+		case dbTypeTimestamp:
+			return val.Format("2006-01-02 15:04:05.999999")
+		default:
+			return val.Format("2006-01-02 15:04:05.999999Z07:00")
+		}
+	default:
+		return escapeFormula(fmt.Sprint(val))
+	}
+}
+
+// escapeFormula - prefix text with ' if spreadsheet would evaluate it as formula (CSV injection)
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// xlsxRowWriter - write rows with native cell types through excelize stream writer,
+// rows over sheet limit continue on next sheet
+type xlsxRowWriter struct {
+	out            io.Writer
+	file           *excelize.File
+	sheet          *excelize.StreamWriter
+	sheets         int
+	row            int
+	columns        []models.ColumnInfo
+	header         []any
+	cells          []any
+	dateStyle      int
+	timestampStyle int
+}
+
+func newXLSXRowWriter(out io.Writer) (*xlsxRowWriter, error) {
+	f := excelize.NewFile()
+
+	dateFormat, timestampFormat := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	timestampStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &timestampFormat})
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	sheet, err := f.NewStreamWriter(f.GetSheetName(0))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &xlsxRowWriter{
+		out:            out,
+		file:           f,
+		sheet:          sheet,
+		sheets:         1,
+		dateStyle:      dateStyle,
+		timestampStyle: timestampStyle,
+	}, nil
+}
+
+func (x *xlsxRowWriter) WriteHeader(columns []models.ColumnInfo) error {
+	x.columns = columns
+	x.cells = make([]any, len(columns))
+	x.header = make([]any, len(columns))
+	for i, col := range columns {
+		x.header[i] = col.Name
+	}
+	return x.writeHeader()
+}
+
+func (x *xlsxRowWriter) writeHeader() error {
+	x.row = 1
+	return x.sheet.SetRow("A1", x.header)
+}
+
+func (x *xlsxRowWriter) WriteRow(values []any) error {
+	if x.row == excelize.TotalRows {
+		if err := x.nextSheet(); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range values {
+		x.cells[i] = x.cellValue(v, x.columns[i].Type)
+	}
+
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sheet.SetRow(cell, x.cells)
+}
+
+// nextSheet - finish current sheet and continue on new one
+func (x *xlsxRowWriter) nextSheet() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	x.sheets++
+	name := fmt.Sprintf("Sheet%d", x.sheets)
+	if _, err := x.file.NewSheet(name); err != nil {
+		return err
+	}
+
+	sheet, err := x.file.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+	x.sheet = sheet
+
+	return x.writeHeader()
+}
+
+// cellValue - convert DB value to native cell value (text cells are never evaluated as formulas)
+func (x *xlsxRowWriter) cellValue(v any, dbType string) any {
+	switch val := v.(type) {
+	case string:
+		if dbType == dbTypeNumeric {
+			if f, err := strconv.ParseFloat(val, 64); err == nil {
+				return f
+			}
+		}
+		return val
+	case time.Time:
+		if dbType == dbTypeDate {
+			return excelize.Cell{StyleID: x.dateStyle, Value: val}
+		}
+		// Excel has no time zones, timestamptz is written in UTC
+		if dbType != dbTypeTimestamp {
+			val = val.UTC()
+		}
+		return excelize.Cell{StyleID: x.timestampStyle, Value: val}
+	default:
+		return val
+	}
+}
+
+func (x *xlsxRowWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
+
+// Discard - release temporary files of unfinished workbook
+func (x *xlsxRowWriter) Discard() {
+	_ = x.file.Close()
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+type importService struct {
+	repo domain.Repository
+	log  *slog.Logger
+}
+
+func newImportService(repo domain.Repository, log *slog.Logger) domain.ImportService {
+	return &importService{repo: repo, log: log}
+}
+
+// List - return import records (newest first)
+func (s *importService) List(ctx context.Context, limit, offset int) ([]models.Import, error) {
+	const op = "service.imports.List"
+
+	imports, err := s.repo.Import().List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imports, nil
+}
+
+// Get - return import record by id
+func (s *importService) Get(ctx context.Context, id int64) (models.Import, error) {
+	const op = "service.imports.Get"
+
+	imp, err := s.repo.Import().Get(ctx, id)
+	if err != nil {
+		return models.Import{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return imp, nil
+}
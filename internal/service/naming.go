@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+)
+
+// Naming policies for column names
+const (
+	// namingKeep - keep trimmed header as is
+	namingKeep = "keep"
+	// namingSnakeCase - lower snake_case, reserved words escaped
+	namingSnakeCase = "snake_case"
+	// namingTranslit - snake_case with Cyrillic transliterated to Latin
+	namingTranslit = "translit"
+)
+
+// maxIdentifierLen - Postgres identifier limit in bytes (NAMEDATALEN - 1)
+const maxIdentifierLen = 63
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// reservedWords - Postgres reserved key words (can not be used as unquoted column names)
+var reservedWords = map[string]struct{}{
+	"all": {}, "analyse": {}, "analyze": {}, "and": {}, "any": {}, "array": {}, "as": {}, "asc": {},
+	"asymmetric": {}, "both": {}, "case": {}, "cast": {}, "check": {}, "collate": {}, "column": {},
+	"constraint": {}, "create": {}, "current_catalog": {}, "current_date": {}, "current_role": {},
+	"current_time": {}, "current_timestamp": {}, "current_user": {}, "default": {}, "deferrable": {},
+	"desc": {}, "distinct": {}, "do": {}, "else": {}, "end": {}, "except": {}, "false": {}, "fetch": {},
+	"for": {}, "foreign": {}, "from": {}, "grant": {}, "group": {}, "having": {}, "in": {},
+	"initially": {}, "intersect": {}, "into": {}, "lateral": {}, "leading": {}, "limit": {},
+	"localtime": {}, "localtimestamp": {}, "not": {}, "null": {}, "offset": {}, "on": {}, "only": {},
+	"or": {}, "order": {}, "placing": {}, "primary": {}, "references": {}, "returning": {},
+	"select": {}, "session_user": {}, "some": {}, "symmetric": {}, "system_user": {}, "table": {},
+	"then": {}, "to": {}, "trailing": {}, "true": {}, "union": {}, "unique": {}, "user": {},
+	"using": {}, "variadic": {}, "when": {}, "where": {}, "window": {}, "with": {},
+}
+
+// columnNamer - make unique column names from headers by naming policy
+type columnNamer struct {
+	policy string
+	used   map[string]struct{}
+}
+
+func newColumnNamer(policy string) *columnNamer {
+	return &columnNamer{policy: policy, used: make(map[string]struct{})}
+}
+
+// name - return unique column name for header of column i
+func (n *columnNamer) name(header string, i int) string {
+	name := strings.TrimSpace(header)
+
+	switch n.policy {
+	case namingSnakeCase:
+		name = toSnakeCase(name, false)
+	case namingTranslit:
+		name = toSnakeCase(name, true)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("col_%d", i+1)
+	}
+
+	if n.policy == namingSnakeCase || n.policy == namingTranslit {
+		if _, reserved := reservedWords[name]; reserved {
+			name += "_"
+		}
+	}
+
+	return n.unique(truncateIdentifier(name, maxIdentifierLen))
+}
+
+// unique - add numeric suffix while name is used, keeping name within identifier limit
+func (n *columnNamer) unique(name string) string {
+	candidate := name
+	for count := 1; ; count++ {
+		if _, exists := n.used[candidate]; !exists {
+			break
+		}
+		suffix := fmt.Sprintf("_%d", count)
+		candidate = truncateIdentifier(name, maxIdentifierLen-len(suffix)) + suffix
+	}
+
+	n.used[candidate] = struct{}{}
+	return candidate
+}
+
+// toSnakeCase - convert "Дата оплаты (руб.)" to "дата_оплаты_руб" or "data_oplaty_rub"
+func toSnakeCase(s string, translit bool) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	underscore := false
+
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// camelCase boundary: "orderId" --> "order_id"
+			if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				underscore = true
+			}
+			if underscore && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			underscore = false
+
+			r = unicode.ToLower(r)
+			if latin, ok := cyrillicToLatin[r]; ok && translit {
+				sb.WriteString(latin)
+				continue
+			}
+			if translit && r > unicode.MaxASCII {
+				underscore = true
+				continue
+			}
+			sb.WriteRune(r)
+		default:
+			underscore = true
+		}
+	}
+
+	name := sb.String()
+	// unquoted identifier can not start with digit
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "c_" + name
+	}
+	return name
+}
+
+// truncateIdentifier - cut name to max bytes on rune boundary
+func truncateIdentifier(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+	name = name[:max]
+	for !utf8.ValidString(name) {
+		name = name[:len(name)-1]
+	}
+	return name
+}
+
+// tableNameTemplateRegexp - date placeholder of table name, parts are joined by _ (e.g. {yyyy_mm})
+var tableNameTemplateRegexp = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// tableNameDateParts - parts of date placeholder with time layouts
+var tableNameDateParts = map[string]string{
+	"yyyy": "2006",
+	"mm":   "01",
+	"dd":   "02",
+	"hh":   "15",
+	"mi":   "04",
+	"ss":   "05",
+}
+
+// expandTableName - replace date placeholders of table name by parts of t
+func expandTableName(name string, t time.Time) (string, error) {
+	var err error
+	expanded := tableNameTemplateRegexp.ReplaceAllStringFunc(name, func(placeholder string) string {
+		parts := strings.Split(strings.Trim(placeholder, "{}"), "_")
+		for i, part := range parts {
+			layout, ok := tableNameDateParts[part]
+			if !ok {
+				err = fmt.Errorf("unknown placeholder %s: %w", placeholder, domain.ErrInvalidTableName)
+				return placeholder
+			}
+			parts[i] = t.Format(layout)
+		}
+		return strings.Join(parts, "_")
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// rejectsSuffix - suffix of table with rejected rows of upload (same as in repositories)
+const rejectsSuffix = "_rejects"
+
+// serviceTableNames - tables of service itself (created by migrations), uploads can not touch them
+var serviceTableNames = map[string]struct{}{
+	"imports":           {},
+	"table_profiles":    {},
+	"managed_tables":    {},
+	"schema_migrations": {},
+}
+
+// reservedTableName - check if name is service table or its rejects table (case-insensitive as names of SQLite)
+func reservedTableName(name string) bool {
+	_, reserved := serviceTableNames[strings.TrimSuffix(strings.ToLower(name), rejectsSuffix)]
+	return reserved
+}
+
+// validTableName - check that name is already sanitized and not reserved by service
+func validTableName(name string) bool {
+	return name != "" && sanitizeTableName(name) == name && !reservedTableName(name)
+}
+
+// versionedTableName - return name with version suffix (_v2, _v3, ...) which fits identifier limit
+func versionedTableName(name string, version int) string {
+	suffix := "_v" + strconv.Itoa(version)
+	return truncateIdentifier(name, maxIdentifierLen-len(suffix)) + suffix
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 
 	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -20,14 +21,14 @@ func newFileParserService(log *slog.Logger) domain.FileParserService {
 }
 
 // Parse - parsing file to Table from io.Reader with extension(.csv, .xlsx)
-func (s *fileParserService) Parse(ctx context.Context, r io.Reader, extension string) ([][]string, error) {
+func (s *fileParserService) Parse(ctx context.Context, r io.Reader, extension string) (models.Sheet, error) {
 	const op = "service.parser.Parse"
 	log := s.log.With("op", op)
 
 	// context checking
 	select {
 	case <-ctx.Done():
-		return nil, fmt.Errorf("%s: context canceled: %w", op, ctx.Err())
+		return models.Sheet{}, fmt.Errorf("%s: context canceled: %w", op, ctx.Err())
 	default:
 	}
 
@@ -40,18 +41,18 @@ func (s *fileParserService) Parse(ctx context.Context, r io.Reader, extension st
 		log.Debug("parsing .XLSX")
 		return s.parseXLSX(ctx, r)
 	default:
-		return nil, fmt.Errorf("%s: failed to read file: %s: %w", op, extension, domain.ErrUnsupportedExtension)
+		return models.Sheet{}, fmt.Errorf("%s: failed to read file: %s: %w", op, extension, domain.ErrUnsupportedExtension)
 	}
 }
 
-func (s *fileParserService) parseCSV(ctx context.Context, r io.Reader) ([][]string, error) {
+func (s *fileParserService) parseCSV(ctx context.Context, r io.Reader) (models.Sheet, error) {
 	const op = "service.parser.parseCSV"
 	log := s.log.With("op", op)
 
 	// context checking
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return models.Sheet{}, ctx.Err()
 	default:
 	}
 
@@ -60,15 +61,53 @@ func (s *fileParserService) parseCSV(ctx context.Context, r io.Reader) ([][]stri
 
 	rows, err := reader.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+		return models.Sheet{}, fmt.Errorf("failed to read CSV: %w", err)
 	}
 
 	log.Debug("rows parsed", "count", len(rows))
 
-	return rows, nil
+	return models.Sheet{Rows: rows}, nil
 }
 
-func (s *fileParserService) parseXLSX(ctx context.Context, r io.Reader) ([][]string, error) {
+// ParseSheets - parsing all sheets of file from io.Reader with extension(.csv, .xlsx)
+func (s *fileParserService) ParseSheets(ctx context.Context, r io.Reader, extension string) ([]models.Sheet, error) {
+	const op = "service.parser.ParseSheets"
+	log := s.log.With("op", op)
+
+	// context checking
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: context canceled: %w", op, ctx.Err())
+	default:
+	}
+
+	// choose extension
+	switch extension {
+	case domain.ExtCSV:
+		log.Debug("parsing .CSV")
+		sheet, err := s.parseCSV(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return []models.Sheet{sheet}, nil
+	case domain.ExtXLSX:
+		log.Debug("parsing all sheets of .XLSX")
+		return s.parseXLSXSheets(ctx, r, -1)
+	default:
+		return nil, fmt.Errorf("%s: failed to read file: %s: %w", op, extension, domain.ErrUnsupportedExtension)
+	}
+}
+
+func (s *fileParserService) parseXLSX(ctx context.Context, r io.Reader) (models.Sheet, error) {
+	sheets, err := s.parseXLSXSheets(ctx, r, 1)
+	if err != nil {
+		return models.Sheet{}, err
+	}
+	return sheets[0], nil
+}
+
+// parseXLSXSheets - parse first limit sheets of workbook (-1 - all sheets)
+func (s *fileParserService) parseXLSXSheets(ctx context.Context, r io.Reader, limit int) ([]models.Sheet, error) {
 	const op = "service.parser.parseXLSX"
 	log := s.log.With("op", op)
 
@@ -90,13 +129,23 @@ func (s *fileParserService) parseXLSX(ctx context.Context, r io.Reader) ([][]str
 		return nil, fmt.Errorf("%s: %w", op, domain.ErrEmptyData)
 	}
 
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to read XLSX: %w", op, err)
+	count := f.SheetCount
+	if limit >= 0 && limit < count {
+		count = limit
 	}
 
-	log.Debug("rows parsed", "count", len(rows))
+	sheets := make([]models.Sheet, 0, count)
+	for i := 0; i < count; i++ {
+		sheetName := f.GetSheetName(i)
+		rows, err := f.GetRows(sheetName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read XLSX: %w", op, err)
+		}
+
+		log.Debug("rows parsed", "sheet", sheetName, "count", len(rows))
+
+		sheets = append(sheets, models.Sheet{Name: sheetName, Rows: rows})
+	}
 
-	return rows, nil
+	return sheets, nil
 }
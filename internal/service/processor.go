@@ -2,74 +2,681 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/tmozzze/SQL_Converter/internal/config"
 	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 )
 
 type processorService struct {
-	repo     domain.Repository
-	parser   domain.FileParserService
-	analyzer domain.SchemaAnalyzerService
-	log      *slog.Logger
+	repo      domain.Repository
+	parser    domain.FileParserService
+	analyzer  domain.SchemaAnalyzerService
+	converter *valueConverter
+
+	maxErrors       int
+	maxErrorPercent float64
+	allowedSchemas  []string
+
+	idempotencyWindow time.Duration
+
+	// maxUnzippedSize - limit of uncompressed entries of archive (0 - no limit)
+	maxUnzippedSize int64
+
+	log *slog.Logger
 }
 
 func newProcessorService(
 	repo domain.Repository,
 	parser domain.FileParserService,
 	analyzer domain.SchemaAnalyzerService,
+	converter *valueConverter,
+	cfg config.ImportCfg,
 	log *slog.Logger,
 ) domain.ProcessorService {
 	return &processorService{
-		repo:     repo,
-		parser:   parser,
-		analyzer: analyzer,
-		log:      log,
+		repo:            repo,
+		parser:          parser,
+		analyzer:        analyzer,
+		converter:       converter,
+		maxErrors:       cfg.MaxErrors,
+		maxErrorPercent: cfg.MaxErrorPercent,
+		allowedSchemas:  cfg.AllowedSchemas,
+
+		idempotencyWindow: cfg.IdempotencyWindow,
+
+		maxUnzippedSize: cfg.MaxUnzippedSize,
+
+		log: log,
+	}
+}
+
+// UploadFile - processing file (analyze, create table, save data) and record it in imports catalog
+func (s *processorService) UploadFile(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions) (models.ImportResult, error) {
+	var result models.ImportResult
+	err := s.withKey(ctx, opts.IdempotencyKey, func(ctx context.Context) error {
+		var err error
+		result, err = s.uploadFile(ctx, tableName, file, extension, opts)
+		return err
+	})
+	return result, err
+}
+
+// withKey - run fn holding lock of idempotency key, so concurrent request with the same key waits
+// for the first one and replays its import instead of loading file again
+func (s *processorService) withKey(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	if key == "" || s.idempotencyWindow <= 0 {
+		return fn(ctx)
+	}
+	return s.repo.Import().LockKey(ctx, key, fn)
+}
+
+// uploadFile - UploadFile under lock of idempotency key
+func (s *processorService) uploadFile(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions) (models.ImportResult, error) {
+	const op = "service.processor.UploadFile"
+	log := s.log.With("op", op)
+
+	started := time.Now()
+	source := newSourceReader(file)
+
+	// go to DB (repeated request with the same key)
+	if opts.IdempotencyKey != "" && s.idempotencyWindow > 0 {
+		original, err := s.repo.Import().FindByIdempotencyKey(ctx, opts.IdempotencyKey, started.Add(-s.idempotencyWindow))
+		switch {
+		case errors.Is(err, domain.ErrImportNotFound):
+			// first request with the key
+		case err != nil:
+			return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		default:
+			if checksum, _ := source.finish(); checksum != original.Checksum {
+				return models.ImportResult{}, fmt.Errorf("%s: %q: %w", op, opts.IdempotencyKey, domain.ErrIdempotencyKeyReused)
+			}
+			log.Info("upload replayed", slog.String("key", opts.IdempotencyKey), slog.Int64("import_id", original.ID))
+			return replayResult(original), nil
+		}
+	}
+
+	// go to DB (reserve import id, lineage columns refer to it; rejected rows reserve it if needed)
+	var importID int64
+	if opts.Lineage {
+		id, err := s.repo.Import().NextID(ctx)
+		if err != nil {
+			return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		importID = id
+	}
+
+	result, err := s.upload(ctx, tableName, source, extension, opts, importID)
+	if result.Replayed {
+		log.Info("upload replayed", slog.String("checksum", source.checksum()), slog.Int64("import_id", result.ImportID))
+		return result, nil
+	}
+	if result.ImportID != 0 {
+		importID = result.ImportID
+	}
+
+	// table name is resolved only on success, otherwise requested one is recorded
+	target := models.Table{Schema: opts.Schema, Name: sanitizeTableName(tableName)}
+	if opts.Table != "" {
+		target.Name = opts.Table
 	}
+	if result.Table.Name != "" {
+		target = result.Table
+	}
+
+	checksum, size := source.finish()
+	imp := models.Import{
+		ID:             importID,
+		Filename:       tableName,
+		TableName:      target.QualifiedName(),
+		Checksum:       checksum,
+		Size:           size,
+		Rows:           result.Rows,
+		Columns:        len(result.Table.Columns),
+		Rejected:       result.Rejected,
+		Schema:         result.Table,
+		Duration:       time.Since(started),
+		Status:         models.ImportStatusSuccess,
+		IdempotencyKey: opts.IdempotencyKey,
+	}
+	if err != nil {
+		imp.Status = models.ImportStatusFailed
+		imp.Error = err.Error()
+	}
+
+	// go to DB (record import), request may be canceled --> record anyway
+	id, recErr := s.repo.Import().Create(context.WithoutCancel(ctx), imp)
+	if recErr != nil {
+		log.Warn("failed to record import", slog.Any("err", recErr))
+	}
+	result.ImportID = id
+
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	// go to DB (mark table as managed by service)
+	if err := s.repo.Table().Register(context.WithoutCancel(ctx), result.Table.QualifiedName(), id); err != nil {
+		log.Warn("failed to register table", slog.Any("err", err))
+	}
+
+	return result, nil
 }
 
-// UploadFile - processing file (analyze, create table, save data)
-func (s *processorService) UploadFile(ctx context.Context, tableName string, file io.Reader, extension string) error {
+// upload - parse, analyze, create table and save data (importID - reserved id for lineage columns)
+func (s *processorService) upload(ctx context.Context, tableName string, file *sourceReader, extension string, opts models.UploadOptions, importID int64) (models.ImportResult, error) {
 	const op = "service.processor.UploadFile"
 	log := s.log.With("op", op)
 
+	// target schema
+	if err := s.checkSchema(opts.Schema); err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// table name (version suffix is chosen at creation)
+	cleanTableName, err := requestedTableName(tableName, opts)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// parsing
+	sheet, err := s.parser.Parse(ctx, file, extension)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: parsing failed: %w", op, err)
+	}
+
+	// analyzing
+	table, err := s.analyzer.Analyze(ctx, cleanTableName, sheet.Rows)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: analysis failed: %w", op, err)
+	}
+	table.Schema = opts.Schema
+
+	// go to DB (same file is already appended to table, e.g. retry of request without key;
+	// request with key is repeated only by its key, forced one appends file on purpose)
+	if opts.Mode == models.UploadModeAppend && opts.IdempotencyKey == "" && !opts.Force && s.idempotencyWindow > 0 {
+		checksum, _ := file.finish()
+		since := time.Now().Add(-s.idempotencyWindow)
+		original, err := s.repo.Import().FindByChecksum(ctx, table.QualifiedName(), checksum, since)
+		switch {
+		case errors.Is(err, domain.ErrImportNotFound):
+			// new file
+		case err != nil:
+			return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		default:
+			return replayResult(original), nil
+		}
+	}
+
+	if opts.Lineage {
+		table.Lineage = &models.Lineage{
+			ImportID:    importID,
+			SourceFile:  tableName,
+			SourceSheet: sheet.Name,
+			LoadedAt:    time.Now(),
+		}
+	}
+
+	// converting, rows failed conversion are checked against error budget before table is touched
+	data, err := s.convertData(table, sheet.Rows[1:])
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		changes []models.SchemaChange
+		loaded  int
+		rejects []models.RejectedRow
+	)
+
+	// go to DB (table and data are one transaction, failed insert leaves replaced table as it was)
+	err = s.repo.Atomic(ctx, func(ctx context.Context) error {
+		var err error
+		changes, err = s.prepareTable(ctx, &table, opts)
+		if err != nil {
+			return err
+		}
+
+		loaded, rejects, err = s.saveData(ctx, table, data)
+		if err != nil {
+			return err
+		}
+
+		if len(rejects) > 0 {
+			log.Warn("rows rejected", "table", table.QualifiedName(), "count", len(rejects))
+			importID, err = s.saveRejects(ctx, table, importID, opts, rejects)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// go to DB (save profile), data is already loaded --> not fatal
+	if err := s.repo.Profile().Save(ctx, table); err != nil {
+		log.Warn("failed to save profile", slog.Any("err", err))
+	}
+
+	log.Debug("file processed successfully", "table", table.QualifiedName(), "rows", loaded)
+
+	return models.ImportResult{ImportID: importID, Table: table, Rows: loaded, Rejected: len(rejects), Rejects: rejects, Changes: changes}, nil
+}
+
+// saveRejects - save rejected rows of import (rows of earlier imports are kept in append mode),
+// return import id (reserved here unless lineage has already done it)
+func (s *processorService) saveRejects(ctx context.Context, table models.Table, importID int64, opts models.UploadOptions, rejects []models.RejectedRow) (int64, error) {
+	if importID == 0 {
+		id, err := s.repo.Import().NextID(ctx)
+		if err != nil {
+			return 0, err
+		}
+		importID = id
+	}
+
+	replace := opts.Mode != models.UploadModeAppend
+	if err := s.repo.Table().SaveRejects(ctx, table, importID, replace, rejects); err != nil {
+		return 0, fmt.Errorf("repo save rejects failed: %w", err)
+	}
+
+	return importID, nil
+}
+
+// replayResult - return result of original import for repeated upload
+func replayResult(original models.Import) models.ImportResult {
+	return models.ImportResult{
+		ImportID: original.ID,
+		Table:    original.Schema,
+		Rows:     original.Rows,
+		Rejected: original.Rejected,
+		Replayed: true,
+	}
+}
+
+// prepareTable - create table (replace mode, new table) or add columns and widen types of existing table (append mode),
+// with suffix conflict policy table is renamed to created version
+func (s *processorService) prepareTable(ctx context.Context, table *models.Table, opts models.UploadOptions) ([]models.SchemaChange, error) {
+	const op = "service.processor.prepareTable"
+	log := s.log.With("op", op)
+
+	if opts.Mode == models.UploadModeAppend {
+		changes, err := s.repo.Table().PlanChanges(ctx, *table)
+		switch {
+		case errors.Is(err, domain.ErrTableNotFound):
+			// nothing to append to --> create
+		case err != nil:
+			return nil, fmt.Errorf("plan changes failed: %w", err)
+		case len(changes) == 0:
+			return nil, nil
+		case opts.Strict:
+			return nil, &domain.SchemaDriftError{Changes: changes}
+		default:
+			if err := s.repo.Table().ApplyChanges(ctx, *table, changes); err != nil {
+				return nil, fmt.Errorf("apply changes failed: %w", err)
+			}
+			log.Info("table schema evolved", "table", table.QualifiedName(), "changes", len(changes))
+			return changes, nil
+		}
+	}
+
+	if opts.OnConflict == models.ConflictSuffix {
+		return nil, s.createVersion(ctx, table)
+	}
+
+	if err := s.repo.Table().Create(ctx, *table); err != nil {
+		return nil, fmt.Errorf("create failed: %w", err)
+	}
+	return nil, nil
+}
+
+// createVersion - create table with first free version suffix (_v2, _v3, ...) and rename table to it.
+// Name is taken by creation itself, concurrent upload of the same name gets next version
+func (s *processorService) createVersion(ctx context.Context, table *models.Table) error {
+	name := table.Name
+
+	for version := 1; version <= maxTableVersions; version++ {
+		candidate := *table
+		if version > 1 {
+			candidate.Name = versionedTableName(name, version)
+		}
+
+		err := s.repo.Table().CreateNew(ctx, candidate)
+		if errors.Is(err, domain.ErrTableExists) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("create failed: %w", err)
+		}
+
+		table.Name = candidate.Name
+		return nil
+	}
+
+	return fmt.Errorf("%s: no free version up to %d: %w", name, maxTableVersions, domain.ErrTableExists)
+}
+
+// requestedTableName - return explicit table name (with date placeholders expanded) or derived from filename
+func requestedTableName(filename string, opts models.UploadOptions) (string, error) {
+	if opts.Table == "" {
+		name := sanitizeTableName(filename)
+		if reservedTableName(name) {
+			return "", fmt.Errorf("%q: %w", name, domain.ErrInvalidTableName)
+		}
+		return name, nil
+	}
+
+	name, err := expandTableName(opts.Table, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if !validTableName(name) {
+		return "", fmt.Errorf("%q: %w", name, domain.ErrInvalidTableName)
+	}
+	return name, nil
+}
+
+// checkSchema - validate target schema name and check it against allowlist ("" - default search_path)
+func (s *processorService) checkSchema(schema string) error {
+	return checkSchema(s.allowedSchemas, schema)
+}
+
+// checkSchema - validate schema name and check it against allowed schemas ("" - default search_path)
+func checkSchema(allowedSchemas []string, schema string) error {
+	if err := validateSchemaName(schema); err != nil || schema == "" {
+		return err
+	}
+
+	if !slices.Contains(allowedSchemas, schema) {
+		return fmt.Errorf("%q: %w", schema, domain.ErrSchemaNotAllowed)
+	}
+
+	return nil
+}
+
+// validateSchemaName - check schema name format ("" - default search_path)
+func validateSchemaName(schema string) error {
+	if schema == "" {
+		return nil
+	}
+	if !schemaNameRegexp.MatchString(schema) || len(schema) > maxIdentifierLen || strings.HasPrefix(schema, "pg_") {
+		return fmt.Errorf("%q: %w", schema, domain.ErrInvalidSchema)
+	}
+	return nil
+}
+
+// convertedData - data rows converted to column types
+type convertedData struct {
+	rows [][]string
+	// numbers - source numbers of converted rows (1-based)
+	numbers []int
+	// rejects - rows failed conversion
+	rejects []models.RejectedRow
+	// total - number of source rows
+	total int
+}
+
+// convertData - convert data rows and check rows failed conversion against error budget
+// (strict mode: any failed row aborts import)
+func (s *processorService) convertData(table models.Table, data [][]string) (convertedData, error) {
+	rows, numbers, rejects := s.converter.Convert(table, data)
+	if len(rejects) > s.errorBudget(len(data)) {
+		return convertedData{}, fmt.Errorf("%d rows failed conversion: %w", len(rejects), domain.ErrTooManyErrors)
+	}
+
+	return convertedData{rows: rows, numbers: numbers, rejects: rejects, total: len(data)}, nil
+}
+
+// saveData - insert converted rows, return number of loaded rows and rejected rows
+func (s *processorService) saveData(ctx context.Context, table models.Table, data convertedData) (int, []models.RejectedRow, error) {
+	return s.saveDataWithin(ctx, table, data, s.errorBudget(data.total)-len(data.rejects))
+}
+
+// saveDataWithin - insert converted rows, at most maxErrors rows may be rejected by DB in tolerant mode
+func (s *processorService) saveDataWithin(ctx context.Context, table models.Table, data convertedData, maxErrors int) (int, []models.RejectedRow, error) {
+	if data.total == 0 {
+		return 0, nil, nil
+	}
+
+	// lineage keeps numbers of source rows, not of converted ones
+	if table.Lineage != nil {
+		lineage := *table.Lineage
+		lineage.RowNumbers = data.numbers
+		table.Lineage = &lineage
+	}
+
+	// strict mode: any failed insert aborts import
+	if s.strict() {
+		if err := s.repo.Table().SaveData(ctx, table, data.rows); err != nil {
+			return 0, nil, fmt.Errorf("repo save failed: %w", err)
+		}
+		return len(data.rows), nil, nil
+	}
+
+	// tolerant mode: failed rows are rejected until error budget is exceeded
+	failed, err := s.repo.Table().SaveDataTolerant(ctx, table, data.rows, maxErrors)
+	if err != nil {
+		return 0, nil, fmt.Errorf("repo save failed: %w", err)
+	}
+
+	rejects := data.rejects
+	for _, reject := range failed {
+		reject.Row = data.numbers[reject.Row-1]
+		rejects = append(rejects, reject)
+	}
+	sort.Slice(rejects, func(i, j int) bool { return rejects[i].Row < rejects[j].Row })
+
+	return len(data.rows) - len(failed), rejects, nil
+}
+
+// strict - check if no error budget is configured (any failed row aborts import)
+func (s *processorService) strict() bool {
+	return s.maxErrors <= 0 && s.maxErrorPercent <= 0
+}
+
+// errorBudget - return max number of rejected rows for import of total rows (0 in strict mode)
+func (s *processorService) errorBudget(total int) int {
+	if s.strict() {
+		return 0
+	}
+
+	budget := math.MaxInt
+	if s.maxErrors > 0 {
+		budget = s.maxErrors
+	}
+	if s.maxErrorPercent > 0 {
+		if b := int(s.maxErrorPercent * float64(total) / 100); b < budget {
+			budget = b
+		}
+	}
+	return budget
+}
+
+// Preview - processing file (analyze only)
+func (s *processorService) Preview(ctx context.Context, tableName string, file io.Reader, extension string) (models.Table, error) {
+	const op = "service.processor.Preview"
+	log := s.log.With("op", op)
+
 	// table name
 	cleanTableName := sanitizeTableName(tableName)
 
 	// parsing
-	rawData, err := s.parser.Parse(ctx, file, extension)
+	sheet, err := s.parser.Parse(ctx, file, extension)
 	if err != nil {
-		return fmt.Errorf("%s: parsing failed: %w", op, err)
+		return models.Table{}, fmt.Errorf("%s: parsing failed: %w", op, err)
 	}
 
 	// analyzing
-	table, err := s.analyzer.Analyze(ctx, cleanTableName, rawData)
+	table, err := s.analyzer.Analyze(ctx, cleanTableName, sheet.Rows)
 	if err != nil {
-		return fmt.Errorf("%s: analysis failed: %w", op, err)
+		return models.Table{}, fmt.Errorf("%s: analysis failed: %w", op, err)
 	}
 
-	// go to DB (create table)
-	if err := s.repo.Table().Create(ctx, table); err != nil {
-		return fmt.Errorf("%s: create failed: %w", op, err)
+	log.Debug("file analyzed successfully", "table", cleanTableName, "columns", len(table.Columns))
+
+	return table, nil
+}
+
+// Convert - processing file offline (analyze, convert, write SQL script with CREATE TABLE and INSERTs to w)
+func (s *processorService) Convert(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions, w io.Writer) (models.ImportResult, error) {
+	const op = "service.processor.Convert"
+	log := s.log.With("op", op)
+
+	// dialect of script
+	if opts.Dialect != "" && !slices.Contains(models.SQLDialects, opts.Dialect) {
+		return models.ImportResult{}, fmt.Errorf("%s: %q: %w", op, opts.Dialect, domain.ErrUnsupportedDialect)
 	}
 
-	// go to DB (insert data)
-	if len(rawData) > 1 {
-		if err := s.repo.Table().SaveData(ctx, table, rawData[1:]); err != nil {
-			return fmt.Errorf("%s: repo save failed: %w", op, err)
+	// target schema (no DB --> no allowlist, script is reviewed before it is run)
+	if err := validateSchemaName(opts.Schema); err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// table name (no DB --> no conflict policy)
+	cleanTableName, err := requestedTableName(tableName, opts)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// parsing
+	sheet, err := s.parser.Parse(ctx, file, extension)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: parsing failed: %w", op, err)
+	}
+
+	// analyzing
+	table, err := s.analyzer.Analyze(ctx, cleanTableName, sheet.Rows)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: analysis failed: %w", op, err)
+	}
+	table.Schema = opts.Schema
+
+	// converting, rows failed conversion are left out of script (strict mode: any failed row aborts)
+	data, err := s.convertData(table, sheet.Rows[1:])
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// writing script
+	if err := s.repo.Script().WriteScript(ctx, w, opts.Dialect, table, data.rows); err != nil {
+		return models.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("file converted successfully", "table", table.QualifiedName(), "rows", len(data.rows))
+
+	return models.ImportResult{Table: table, Rows: len(data.rows), Rejected: len(data.rejects), Rejects: data.rejects}, nil
+}
+
+// ConvertSQLite - processing file offline (analyze, convert, write SQLite database file to w),
+// every non-empty sheet of workbook becomes table, rows failed conversion are saved in <table>_rejects
+func (s *processorService) ConvertSQLite(ctx context.Context, tableName string, file io.Reader, extension string, opts models.UploadOptions, w io.Writer) ([]models.ImportResult, error) {
+	const op = "service.processor.ConvertSQLite"
+	log := s.log.With("op", op)
+
+	// parsing
+	sheets, err := s.parser.ParseSheets(ctx, file, extension)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parsing failed: %w", op, err)
+	}
+	sheets = slices.DeleteFunc(sheets, func(sheet models.Sheet) bool { return len(sheet.Rows) == 0 })
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrEmptyData)
+	}
+
+	// table names (file is new database --> no conflict policy)
+	names, err := sheetTableNames(tableName, sheets, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tables := make([]models.TableData, len(sheets))
+	results := make([]models.ImportResult, len(sheets))
+
+	for i, sheet := range sheets {
+		// analyzing
+		table, err := s.analyzer.Analyze(ctx, names[i], sheet.Rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: analysis of sheet %q failed: %w", op, sheet.Name, err)
 		}
+
+		// converting, rows failed conversion are saved apart (strict mode: any failed row aborts)
+		data, err := s.convertData(table, sheet.Rows[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: table %s: %w", op, table.Name, err)
+		}
+
+		tables[i] = models.TableData{Table: table, Rows: data.rows, Rejects: data.rejects}
+		results[i] = models.ImportResult{Table: table, Rows: len(data.rows), Rejected: len(data.rejects), Rejects: data.rejects}
 	}
 
-	log.Debug("file processed successfully", "table", cleanTableName, "rows", len(rawData)-1)
+	// writing database file
+	if err := s.repo.DatabaseFile().WriteSQLite(ctx, w, tables); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	return nil
+	log.Debug("file converted to SQLite successfully", "tables", len(tables))
+
+	return results, nil
 }
 
+// sheetTableNames - return table names of sheets: requested (or derived from filename) name of the only sheet,
+// otherwise transliterated sheet names prefixed by explicit table name
+func sheetTableNames(filename string, sheets []models.Sheet, opts models.UploadOptions) ([]string, error) {
+	if len(sheets) == 1 {
+		name, err := requestedTableName(filename, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+
+	var prefix string
+	if opts.Table != "" {
+		p, err := requestedTableName(filename, opts)
+		if err != nil {
+			return nil, err
+		}
+		prefix = p + "_"
+	}
+
+	namer := newColumnNamer(namingTranslit)
+	names := make([]string, len(sheets))
+	for i, sheet := range sheets {
+		names[i] = truncateIdentifier(prefix+namer.name(sheet.Name, i), maxIdentifierLen)
+	}
+	return names, nil
+}
+
+// Profile - return saved schema with column profiles
+func (s *processorService) Profile(ctx context.Context, tableName string) (models.Table, error) {
+	const op = "service.processor.Profile"
+
+	table, err := s.repo.Profile().Get(ctx, tableName)
+	if err != nil {
+		return models.Table{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return table, nil
+}
+
+// maxTableVersions - max version suffix tried by suffix conflict policy
+const maxTableVersions = 1000
+
+// schemaNameRegexp - schema names accepted as is (no quoting surprises, "pg_" prefix is reserved)
+var schemaNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
 func sanitizeTableName(filename string) string {
 	name := strings.TrimSuffix(filename, filepath.Ext(filename))
 	reg := regexp.MustCompile(`[^a-zA-Z0-9]+`)
@@ -78,5 +685,5 @@ func sanitizeTableName(filename string) string {
 	if name == "" {
 		return "imported_table"
 	}
-	return name
+	return truncateIdentifier(name, maxIdentifierLen)
 }
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+const (
+	// maxTrackedValues - max distinct values counted exactly per column
+	maxTrackedValues = 10000
+	// kmvSize - number of minimal hashes for distinct estimate (K-Minimum Values)
+	kmvSize = 256
+)
+
+// columnProfiler - accumulate statistics of one column
+type columnProfiler struct {
+	dataType models.DataType
+	topK     int
+	samples  int
+
+	profile  models.ColumnProfile
+	counts   map[string]int
+	overflow bool
+	hashes   kmvSketch
+
+	sum      float64
+	numbers  int
+	min, max float64
+}
+
+func newColumnProfiler(dataType models.DataType, topK int, samples int) *columnProfiler {
+	return &columnProfiler{
+		dataType: dataType,
+		topK:     topK,
+		samples:  samples,
+		counts:   make(map[string]int),
+	}
+}
+
+func (p *columnProfiler) add(val string) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		p.profile.NullCount++
+		return
+	}
+
+	// length
+	if l := utf8.RuneCountInString(val); l > p.profile.MaxLength {
+		p.profile.MaxLength = l
+	}
+
+	// distinct and top values
+	p.hashes.add(val)
+	if _, ok := p.counts[val]; ok || len(p.counts) < maxTrackedValues {
+		if !ok && len(p.profile.Samples) < p.samples {
+			p.profile.Samples = append(p.profile.Samples, val)
+		}
+		p.counts[val]++
+	} else {
+		p.overflow = true
+	}
+
+	// min, max, mean
+	switch p.dataType {
+	case models.DataTypeInteger, models.DataTypeFloat:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return
+		}
+		if p.numbers == 0 || f < p.min {
+			p.min, p.profile.Min = f, val
+		}
+		if p.numbers == 0 || f > p.max {
+			p.max, p.profile.Max = f, val
+		}
+		p.sum += f
+		p.numbers++
+	case models.DataTypeJSON:
+	default:
+		if p.profile.Min == "" || val < p.profile.Min {
+			p.profile.Min = val
+		}
+		if p.profile.Max == "" || val > p.profile.Max {
+			p.profile.Max = val
+		}
+	}
+}
+
+func (p *columnProfiler) result() models.ColumnProfile {
+	profile := p.profile
+
+	profile.DistinctCount = len(p.counts)
+	if p.overflow {
+		profile.DistinctCount = p.hashes.estimate()
+	}
+
+	if p.numbers > 0 {
+		mean := p.sum / float64(p.numbers)
+		profile.Mean = &mean
+	}
+
+	top := make([]models.ValueCount, 0, len(p.counts))
+	for v, c := range p.counts {
+		top = append(top, models.ValueCount{Value: v, Count: c})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Value < top[j].Value
+	})
+	if len(top) > p.topK {
+		top = top[:p.topK]
+	}
+	profile.TopValues = top
+
+	return profile
+}
+
+// kmvSketch - K-Minimum Values distinct count estimator
+type kmvSketch struct {
+	hashes []uint64 // sorted ascending, at most kmvSize
+}
+
+func (k *kmvSketch) add(val string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(val))
+	sum := h.Sum64()
+
+	if len(k.hashes) == kmvSize && sum >= k.hashes[kmvSize-1] {
+		return
+	}
+
+	i := sort.Search(len(k.hashes), func(i int) bool { return k.hashes[i] >= sum })
+	if i < len(k.hashes) && k.hashes[i] == sum {
+		return
+	}
+
+	k.hashes = append(k.hashes, 0)
+	copy(k.hashes[i+1:], k.hashes[i:])
+	k.hashes[i] = sum
+
+	if len(k.hashes) > kmvSize {
+		k.hashes = k.hashes[:kmvSize]
+	}
+}
+
+func (k *kmvSketch) estimate() int {
+	if len(k.hashes) < kmvSize {
+		return len(k.hashes)
+	}
+	// (k - 1) / (k-th minimal hash normalized to [0, 1])
+	kth := float64(k.hashes[kmvSize-1]) / float64(^uint64(0))
+	return int(float64(kmvSize-1) / kth)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"log/slog"
 
+	"github.com/tmozzze/SQL_Converter/internal/config"
 	"github.com/tmozzze/SQL_Converter/internal/domain"
 )
 
@@ -10,21 +11,33 @@ type service struct {
 	fileParser     domain.FileParserService
 	schemaAnalyzer domain.SchemaAnalyzerService
 	processor      domain.ProcessorService
+	imports        domain.ImportService
+	tables         domain.TableService
+	export         domain.ExportService
+	batches        domain.BatchService
 	log            *slog.Logger
 }
 
 // NewService - constructor for main service
 func NewService(
 	repo domain.Repository,
+	cfg config.AnalyzerCfg,
+	importCfg config.ImportCfg,
 	log *slog.Logger,
 ) domain.Service {
+	bools := newBoolVocabulary(cfg)
 	parser := newFileParserService(log)
-	analyzer := newSchemaAnalyzerService(log)
-	processor := newProcessorService(repo, parser, analyzer, log)
+	analyzer := newSchemaAnalyzerService(bools, cfg, log)
+	converter := newValueConverter(bools)
+	processor := newProcessorService(repo, parser, analyzer, converter, importCfg, log)
 	return &service{
 		fileParser:     parser,
 		schemaAnalyzer: analyzer,
 		processor:      processor,
+		imports:        newImportService(repo, log),
+		tables:         newTableService(repo, log),
+		export:         newExportService(repo, importCfg.AllowedSchemas, log),
+		batches:        newBatchService(repo, processor, importCfg, log),
 		log:            log,
 	}
 }
@@ -43,3 +56,23 @@ func (s *service) SchemaAnalyzer() domain.SchemaAnalyzerService {
 func (s *service) Processor() domain.ProcessorService {
 	return s.processor
 }
+
+// Imports - return ImportService
+func (s *service) Imports() domain.ImportService {
+	return s.imports
+}
+
+// Tables - return TableService
+func (s *service) Tables() domain.TableService {
+	return s.tables
+}
+
+// Export - return ExportService
+func (s *service) Export() domain.ExportService {
+	return s.export
+}
+
+// Batches - return BatchService
+func (s *service) Batches() domain.BatchService {
+	return s.batches
+}
@@ -1,17 +1,35 @@
 package service_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/database/migrations"
+	"github.com/tmozzze/SQL_Converter/internal/config"
 	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
 	"github.com/tmozzze/SQL_Converter/internal/repository/postgres"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlite"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+	"github.com/xuri/excelize/v2"
 
 	"github.com/tmozzze/SQL_Converter/internal/service"
 )
@@ -23,7 +41,14 @@ func TestProcessorService_UploadFile(t *testing.T) {
 
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	repo := postgres.NewRepository(db, log)
-	svc := service.NewService(repo, log)
+	cfg := config.AnalyzerCfg{
+		BoolTrue:  []string{"true", "yes", "да", "✓"},
+		BoolFalse: []string{"false", "no", "нет"},
+
+		ProfileTopK:    5,
+		ProfileSamples: 5,
+	}
+	svc := service.NewService(repo, cfg, config.ImportCfg{}, log)
 	processor := svc.Processor()
 
 	ctx := context.Background()
@@ -38,10 +63,11 @@ Petr,35,55000.00,true`
 
 		// Waiting CREATE TABLE with true types
 		createQuery := `DROP TABLE IF EXISTS "users" CASCADE;CREATE TABLE IF NOT EXISTS "users" \("name" TEXT, "age" BIGINT, "salary" NUMERIC, "is_active" BOOLEAN\);`
+		mock.ExpectBegin()
 		mock.ExpectExec(createQuery).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
-		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
 		mock.ExpectPrepare(`INSERT INTO "users" \("name", "age", "salary", "is_active"\) VALUES \(\$1, \$2, \$3, \$4\);`)
 
 		mock.ExpectExec(`INSERT INTO "users" .*`).
@@ -54,10 +80,1427 @@ Petr,35,55000.00,true`
 			WithArgs("Petr", "35", "55000.00", "true").
 			WillReturnResult(sqlmock.NewResult(3, 1))
 
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
 		mock.ExpectCommit()
 
-		err := processor.UploadFile(ctx, "users", reader, domain.ExtCSV)
+		expectProfileSave(mock, "users")
+		expectImportSave(mock, "users")
+		expectRegister(mock, "users")
+
+		result, err := processor.UploadFile(ctx, "users", reader, domain.ExtCSV, models.UploadOptions{})
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
+
+		// profile of "age"
+		assert.Equal(t, int64(1), result.ImportID)
+		assert.Equal(t, 3, result.Rows)
+		age := result.Table.Columns[1].Profile
+		assert.Equal(t, "25", age.Min)
+		assert.Equal(t, "35", age.Max)
+		require.NotNil(t, age.Mean)
+		assert.InDelta(t, 30.0, *age.Mean, 1e-9)
+		assert.Equal(t, 3, age.DistinctCount)
+		assert.Equal(t, 0, age.NullCount)
+
+		// profile of "is_active"
+		active := result.Table.Columns[3].Profile
+		assert.Nil(t, active.Mean)
+		assert.Equal(t, []models.ValueCount{{Value: "true", Count: 2}, {Value: "false", Count: 1}}, active.TopValues)
+	})
+
+	t.Run("localized boolean words are converted", func(t *testing.T) {
+		csvData := `id,paid,checked
+1,да,✓
+2,нет,no
+3,Yes,`
+
+		reader := strings.NewReader(csvData)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "payments" \("id" BIGINT, "paid" BOOLEAN, "checked" BOOLEAN\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "payments" .*`)
+		mock.ExpectExec(`INSERT INTO "payments" .*`).
+			WithArgs("1", "true", "true").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO "payments" .*`).
+			WithArgs("2", "false", "false").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(`INSERT INTO "payments" .*`).
+			WithArgs("3", "true", nil).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "payments")
+		expectImportSave(mock, "payments")
+		expectRegister(mock, "payments")
+
+		_, err := processor.UploadFile(ctx, "payments", reader, domain.ExtCSV, models.UploadOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("1/0 column stays integer when numeric bool is disabled", func(t *testing.T) {
+		csvData := `flag
+1
+0
+1`
+
+		reader := strings.NewReader(csvData)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "flags" \("flag" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "flags" .*`)
+		for _, v := range []string{"1", "0", "1"} {
+			mock.ExpectExec(`INSERT INTO "flags" .*`).
+				WithArgs(v).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "flags")
+		expectImportSave(mock, "flags")
+		expectRegister(mock, "flags")
+
+		_, err := processor.UploadFile(ctx, "flags", reader, domain.ExtCSV, models.UploadOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("semantic types are detected", func(t *testing.T) {
+		csvData := `id,payload,ip,network,duration
+6f1c2a4e-8d3b-4c5a-9e7f-0a1b2c3d4e5f,"{""a"": 1}",192.168.0.10,10.0.0.0/8,1h30m
+0b9d7c6e-5f4a-4b3c-8d2e-1f0a9b8c7d6e,"[1,2]",::1,10.1.0.0/16,P3D`
+
+		reader := strings.NewReader(csvData)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "events" \("id" UUID, "payload" JSONB, "ip" INET, "network" CIDR, "duration" INTERVAL\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "events" .*`)
+		mock.ExpectExec(`INSERT INTO "events" .*`).
+			WithArgs("6f1c2a4e-8d3b-4c5a-9e7f-0a1b2c3d4e5f", `{"a": 1}`, "192.168.0.10", "10.0.0.0/8", "01:30:00.000000").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO "events" .*`).
+			WithArgs("0b9d7c6e-5f4a-4b3c-8d2e-1f0a9b8c7d6e", "[1,2]", "::1", "10.1.0.0/16", "P3D").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "events")
+		expectImportSave(mock, "events")
+		expectRegister(mock, "events")
+
+		_, err := processor.UploadFile(ctx, "events", reader, domain.ExtCSV, models.UploadOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func expectProfileSave(mock sqlmock.Sqlmock, table string) {
+	mock.ExpectExec(`INSERT INTO table_profiles`).
+		WithArgs(table, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectImportSave(mock sqlmock.Sqlmock, table string) {
+	mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+		WithArgs(table, table, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+func expectRegister(mock sqlmock.Sqlmock, table string) {
+	schema, name := models.SplitQualifiedName(table)
+	mock.ExpectExec(`INSERT INTO managed_tables .* ON CONFLICT`).
+		WithArgs(schema, name, int64(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestProcessorService_Preview(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	cfg := config.AnalyzerCfg{
+		BoolTrue:      []string{"true"},
+		BoolFalse:     []string{"false"},
+		EnumThreshold: 3,
+		EnumMode:      string(models.EnumModeCheck),
+	}
+	processor := service.NewService(repo, cfg, config.ImportCfg{}, log).Processor()
+
+	csvData := `id,status,comment
+1,paid,first
+2,new,second
+3,paid,third
+4, cancelled ,fourth
+5,new,fifth`
+
+	table, err := processor.Preview(context.Background(), "orders.csv", strings.NewReader(csvData), domain.ExtCSV)
+	require.NoError(t, err)
+
+	assert.Equal(t, "orders", table.Name)
+	require.Len(t, table.Columns, 3)
+	assert.Nil(t, table.Columns[0].EnumValues)
+	assert.Equal(t, []string{"cancelled", "new", "paid"}, table.Columns[1].EnumValues)
+	assert.Nil(t, table.Columns[2].EnumValues)
+}
+
+func TestSchemaAnalyzer_Downgrades(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cfg := config.AnalyzerCfg{BoolTrue: []string{"true"}, BoolFalse: []string{"false"}}
+	analyzer := service.NewService(nil, cfg, config.ImportCfg{}, log).SchemaAnalyzer()
+
+	data := [][]string{{"amount"}, {"1"}, {"2"}, {"3.5"}, {"4"}, {"N/A"}}
+
+	table, err := analyzer.Analyze(context.Background(), "payments", data)
+	require.NoError(t, err)
+
+	col := table.Columns[0]
+	assert.Equal(t, models.DataTypeString, col.Type)
+	assert.Equal(t, []models.TypeChange{
+		{From: models.DataTypeInteger, To: models.DataTypeFloat, Value: "3.5", Row: 3},
+		{From: models.DataTypeFloat, To: models.DataTypeString, Value: "N/A", Row: 5},
+	}, col.Downgrades)
+	assert.InDelta(t, 0.2, col.Confidence, 1e-9)
+}
+
+func TestProcessorService_UploadFileWithTolerance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	cfg := config.AnalyzerCfg{
+		BoolTrue:         []string{"true"},
+		BoolFalse:        []string{"false"},
+		TolerancePercent: 30,
+	}
+	ctx := context.Background()
+
+	csvData := `id,amount
+1,10
+2,20
+3,N/A
+4,40`
+
+	t.Run("rejected row within error budget is saved apart", func(t *testing.T) {
+		processor := service.NewService(repo, cfg, config.ImportCfg{MaxErrors: 1}, log).Processor()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "payments" \("id" BIGINT, "amount" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "payments" .*`)
+		for _, row := range [][]string{{"1", "10"}, {"2", "20"}, {"4", "40"}} {
+			mock.ExpectExec(`SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`INSERT INTO "payments" .*`).
+				WithArgs(row[0], row[1]).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(`RELEASE SAVEPOINT row_insert;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// rejects refer to import, its id is reserved
+		mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('imports', 'id'\)\);`).
+			WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(9))
+		mock.ExpectExec(`SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DROP TABLE IF EXISTS "payments_rejects";CREATE TABLE IF NOT EXISTS "payments_rejects" \(import_id BIGINT, `).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "payments_rejects" .*`)
+		mock.ExpectExec(`INSERT INTO "payments_rejects" .*`).
+			WithArgs(int64(9), 3, "amount", "N/A", "invalid Integer value", []byte(`["3","N/A"]`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "payments")
+		mock.ExpectQuery(`INSERT INTO imports .* id\) VALUES .* RETURNING id;`).
+			WithArgs("payments", "payments", sqlmock.AnyArg(), sqlmock.AnyArg(), 3, 2,
+				1, sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "", int64(9)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec(`INSERT INTO managed_tables .* ON CONFLICT`).
+			WithArgs("", "payments", int64(9)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		result, err := processor.UploadFile(ctx, "payments", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, int64(9), result.ImportID)
+		assert.Equal(t, 3, result.Rows)
+		assert.Equal(t, 1, result.Rejected)
+		assert.InDelta(t, 0.75, result.Table.Columns[1].Confidence, 1e-9)
+	})
+
+	t.Run("strict mode aborts import before table is replaced", func(t *testing.T) {
+		processor := service.NewService(repo, cfg, config.ImportCfg{}, log).Processor()
+
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WithArgs("payments", "payments", sqlmock.AnyArg(), sqlmock.AnyArg(), 0, 0,
+				0, sqlmock.AnyArg(), sqlmock.AnyArg(), "failed", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		_, err := processor.UploadFile(ctx, "payments", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{})
+		assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed insert rolls back replaced table", func(t *testing.T) {
+		processor := service.NewService(repo, cfg, config.ImportCfg{}, log).Processor()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "payments" \("id" BIGINT, "amount" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "payments" .*`)
+		mock.ExpectExec(`INSERT INTO "payments" .*`).
+			WithArgs("1", "10").
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		_, err := processor.UploadFile(ctx, "payments", strings.NewReader("id,amount\n1,10"), domain.ExtCSV, models.UploadOptions{})
+		assert.ErrorContains(t, err, "connection reset")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSchemaAnalyzer_Sampling(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cfg := config.AnalyzerCfg{SampleHead: 5, SampleSize: 10, ProfileTopK: 1}
+	analyzer := service.NewService(nil, cfg, config.ImportCfg{}, log).SchemaAnalyzer()
+
+	data := [][]string{{"id"}}
+	for i := 1; i <= 1000; i++ {
+		data = append(data, []string{strconv.Itoa(i)})
+	}
+
+	table, err := analyzer.Analyze(context.Background(), "numbers", data)
+	require.NoError(t, err)
+
+	col := table.Columns[0]
+	assert.Equal(t, models.DataTypeInteger, col.Type)
+	assert.Equal(t, 1.0, col.Confidence)
+	// profile is collected from all rows
+	assert.Equal(t, "1000", col.Profile.Max)
+}
+
+func TestSchemaAnalyzer_NamingPolicy(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	long := strings.Repeat("очень_длинное_название_", 5)
+	headers := []string{"Дата оплаты (руб.)", "orderId", "select", "2nd value", "", long + "1", long + "2"}
+
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{
+			policy: "keep",
+			want:   []string{"Дата оплаты (руб.)", "orderId", "select", "2nd value", "col_5", "очень_длинное_название_очень_длин", "очень_длинное_название_очень_дли_1"},
+		},
+		{
+			policy: "snake_case",
+			want:   []string{"дата_оплаты_руб", "order_id", "select_", "c_2nd_value", "col_5", "очень_длинное_название_очень_длин", "очень_длинное_название_очень_дли_1"},
+		},
+		{
+			policy: "translit",
+			want:   []string{"data_oplaty_rub", "order_id", "select_", "c_2nd_value", "col_5", "ochen_dlinnoe_nazvanie_ochen_dlinnoe_nazvanie_ochen_dlinnoe_naz", "ochen_dlinnoe_nazvanie_ochen_dlinnoe_nazvanie_ochen_dlinnoe_n_1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			cfg := config.AnalyzerCfg{NamingPolicy: tt.policy}
+			analyzer := service.NewService(nil, cfg, config.ImportCfg{}, log).SchemaAnalyzer()
+
+			table, err := analyzer.Analyze(context.Background(), "names", [][]string{headers})
+			require.NoError(t, err)
+
+			got := make([]string, len(table.Columns))
+			for i, col := range table.Columns {
+				got[i] = col.Name
+				assert.LessOrEqual(t, len(col.Name), 63)
+				assert.Equal(t, strings.TrimSpace(headers[i]), col.Source)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProcessorService_UploadFileRecordsFailedImport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+
+	content := "some text"
+	sum := sha256.Sum256([]byte(content))
+
+	mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+		WithArgs("notes.txt", "notes", hex.EncodeToString(sum[:]), int64(len(content)), 0, 0,
+			0, sqlmock.AnyArg(), sqlmock.AnyArg(), "failed", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	_, err = processor.UploadFile(context.Background(), "notes.txt", strings.NewReader(content), ".txt", models.UploadOptions{})
+	assert.ErrorIs(t, err, domain.ErrUnsupportedExtension)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessorService_UploadFileAppend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+	ctx := context.Background()
+
+	csvData := `id,amount,region
+1,10.5,north
+70000,3,south`
+
+	expectExisting := func() {
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "sales").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("id", "smallint").
+				AddRow("amount", "bigint").
+				AddRow("comment", "text"))
+	}
+
+	t.Run("columns are added and types widened", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectExisting()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ALTER TABLE "sales" ALTER COLUMN "id" TYPE bigint USING "id"::bigint;` +
+			`ALTER TABLE "sales" ALTER COLUMN "amount" TYPE numeric USING "amount"::numeric;` +
+			`ALTER TABLE "sales" ADD COLUMN "region" text;`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		mock.ExpectExec(`SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "sales" \("id", "amount", "region"\)`)
+		mock.ExpectExec(`INSERT INTO "sales"`).WithArgs("1", "10.5", "north").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO "sales"`).WithArgs("70000", "3", "south").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "sales")
+		expectImportSave(mock, "sales")
+		expectRegister(mock, "sales")
+
+		result, err := processor.UploadFile(ctx, "sales", strings.NewReader(csvData), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, []models.SchemaChange{
+			{Kind: models.SchemaChangeWidenType, Column: "id", From: "smallint", To: "bigint"},
+			{Kind: models.SchemaChangeWidenType, Column: "amount", From: "bigint", To: "numeric"},
+			{Kind: models.SchemaChangeAddColumn, Column: "region", To: "text"},
+		}, result.Changes)
+	})
+
+	t.Run("strict append rejects drift", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectExisting()
+		mock.ExpectRollback()
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WithArgs("sales", "sales", sqlmock.AnyArg(), sqlmock.AnyArg(), 0, 0,
+				0, sqlmock.AnyArg(), sqlmock.AnyArg(), "failed", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		_, err := processor.UploadFile(ctx, "sales", strings.NewReader(csvData), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend, Strict: true})
+		assert.ErrorIs(t, err, domain.ErrSchemaDrift)
+		assert.ErrorContains(t, err, "column amount bigint -> numeric")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new enum value drops check", func(t *testing.T) {
+		analyzerCfg := config.AnalyzerCfg{EnumThreshold: 3, EnumMode: string(models.EnumModeCheck)}
+		processor := service.NewService(repo, analyzerCfg, config.ImportCfg{}, log).Processor()
+
+		expectCheck := func() {
+			mock.ExpectQuery(`FROM pg_constraint c`).
+				WithArgs("", "orders", "status").
+				WillReturnRows(sqlmock.NewRows([]string{"conname", "pg_get_constraintdef"}).
+					AddRow("orders_status_check", "CHECK ((status = ANY (ARRAY['new'::text, 'vip'::text])))"))
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "orders").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("id", "bigint").
+				AddRow("status", "text"))
+		expectCheck()
+		expectCheck()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ALTER TABLE "orders" DROP CONSTRAINT "orders_status_check";`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		mock.ExpectExec(`SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "orders" \("id", "status"\)`)
+		mock.ExpectExec(`INSERT INTO "orders"`).WithArgs("1", "new").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO "orders"`).WithArgs("2", "gold").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(`INSERT INTO "orders"`).WithArgs("3", "new").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectProfileSave(mock, "orders")
+		expectImportSave(mock, "orders")
+		expectRegister(mock, "orders")
+
+		result, err := processor.UploadFile(ctx, "orders", strings.NewReader("id,status\n1,new\n2,gold\n3,new"),
+			domain.ExtCSV, models.UploadOptions{Mode: models.UploadModeAppend})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, []models.SchemaChange{
+			{Kind: models.SchemaChangeDropCheck, Column: "status", From: "text", To: "text"},
+		}, result.Changes)
+	})
+}
+
+func TestProcessorService_UploadFileToSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	importCfg := config.ImportCfg{AllowedSchemas: []string{"public", "finance"}}
+	processor := service.NewService(repo, config.AnalyzerCfg{}, importCfg, log).Processor()
+
+	ctx := context.Background()
+
+	t.Run("table is created in allowed schema", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "finance";DROP TABLE IF EXISTS "finance"."sales" CASCADE;` +
+			`CREATE TABLE IF NOT EXISTS "finance"."sales" \("id" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "finance"."sales" \("id"\) VALUES \(\$1\);`)
+		mock.ExpectExec(`INSERT INTO "finance"."sales" .*`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		expectProfileSave(mock, "finance.sales")
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WithArgs("sales", "finance.sales", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		expectRegister(mock, "finance.sales")
+
+		result, err := processor.UploadFile(ctx, "sales", strings.NewReader("id\n1"), domain.ExtCSV,
+			models.UploadOptions{Schema: "finance"})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, "finance.sales", result.Table.QualifiedName())
+	})
+
+	for _, tc := range []struct {
+		schema string
+		err    error
+	}{
+		{schema: "audit", err: domain.ErrSchemaNotAllowed},
+		{schema: "pg_catalog", err: domain.ErrInvalidSchema},
+		{schema: `fin"; DROP`, err: domain.ErrInvalidSchema},
+	} {
+		t.Run("schema "+tc.schema+" is rejected", func(t *testing.T) {
+			mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+			_, err := processor.UploadFile(ctx, "sales", strings.NewReader("id\n1"), domain.ExtCSV,
+				models.UploadOptions{Schema: tc.schema})
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProcessorService_UploadFileTableName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+	ctx := context.Background()
+
+	t.Run("templated name gets first free suffix", func(t *testing.T) {
+		table := "sales_" + time.Now().Format("2006_01")
+
+		// name is taken by plain CREATE TABLE, failed one is rolled back to its savepoint
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE "` + table + `" \(\);`).
+			WillReturnError(&pq.Error{Code: "42P07"})
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE "` + table + `_v2" \(\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_2;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "` + table + `_v2" \("id" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_3;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "` + table + `_v2" .*`)
+		mock.ExpectExec(`INSERT INTO "` + table + `_v2" .*`).
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_3;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		expectProfileSave(mock, table+"_v2")
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WithArgs("report (1).csv", table+"_v2", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		expectRegister(mock, table+"_v2")
+
+		result, err := processor.UploadFile(ctx, "report (1).csv", strings.NewReader("id\n1"), domain.ExtCSV,
+			models.UploadOptions{Table: "sales_{yyyy_mm}", OnConflict: models.ConflictSuffix})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Equal(t, table+"_v2", result.Table.Name)
+	})
+
+	for _, name := range []string{"Sales Report", "sales_{quarter}", "sales.csv"} {
+		t.Run("name "+name+" is rejected", func(t *testing.T) {
+			mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+			_, err := processor.UploadFile(ctx, "report.csv", strings.NewReader("id\n1"), domain.ExtCSV,
+				models.UploadOptions{Table: name})
+
+			assert.ErrorIs(t, err, domain.ErrInvalidTableName)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProcessorService_UploadFileLineage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+
+	mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('imports', 'id'\)\);`).
+		WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(42))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "orders" \("id" BIGINT, "_import_id" BIGINT, "_source_file" TEXT, ` +
+		`"_source_sheet" TEXT, "_source_row_number" BIGINT, "_loaded_at" TIMESTAMPTZ\);` +
+		`CREATE INDEX ON "orders" \("_import_id"\);`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`INSERT INTO "orders" \("id", "_import_id", "_source_file", "_source_sheet", "_source_row_number", "_loaded_at"\) ` +
+		`VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\);`)
+	for i, id := range []string{"7", "8"} {
+		mock.ExpectExec(`INSERT INTO "orders" .*`).
+			WithArgs(id, int64(42), "orders.csv", nil, int64(i+1), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	expectProfileSave(mock, "orders")
+	mock.ExpectQuery(`INSERT INTO imports .* id\) VALUES .* RETURNING id;`).
+		WithArgs("orders.csv", "orders", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "", int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectExec(`INSERT INTO managed_tables .* ON CONFLICT`).
+		WithArgs("", "orders", int64(42)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	result, err := processor.UploadFile(context.Background(), "orders.csv", strings.NewReader("id\n7\n8"), domain.ExtCSV,
+		models.UploadOptions{Lineage: true})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(42), result.ImportID)
+}
+
+func TestProcessorService_UploadFileIdempotency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	importCfg := config.ImportCfg{IdempotencyWindow: time.Hour}
+	processor := service.NewService(repo, config.AnalyzerCfg{}, importCfg, log).Processor()
+	ctx := context.Background()
+
+	content := "id\n1\n2"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	importRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "filename", "table_name", "checksum", "size_bytes", "rows_count",
+			"columns_count", "rejected_count", "schema", "duration_ms", "status", "error", "idempotency_key", "created_at"}).
+			AddRow(5, "orders.csv", "orders", checksum, int64(len(content)), 2, 1, 0,
+				[]byte(`{"name":"orders","columns":[{"name":"id","type":"Integer"}]}`), 10, "success", "", "retry-1", time.Now())
+	}
+
+	// keyed request holds advisory lock of key in own transaction
+	expectLock := func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\);`).
+			WithArgs("retry-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("request with used key returns original result", func(t *testing.T) {
+		expectLock()
+		mock.ExpectQuery(`FROM imports WHERE idempotency_key = \$1 AND status = 'success'`).
+			WithArgs("retry-1", sqlmock.AnyArg()).
+			WillReturnRows(importRows())
+		mock.ExpectRollback()
+
+		result, err := processor.UploadFile(ctx, "orders.csv", strings.NewReader(content), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend, IdempotencyKey: "retry-1"})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.True(t, result.Replayed)
+		assert.Equal(t, int64(5), result.ImportID)
+		assert.Equal(t, 2, result.Rows)
+		assert.Equal(t, "orders", result.Table.Name)
+	})
+
+	t.Run("used key with another file is rejected", func(t *testing.T) {
+		expectLock()
+		mock.ExpectQuery(`FROM imports WHERE idempotency_key = \$1`).
+			WithArgs("retry-1", sqlmock.AnyArg()).
+			WillReturnRows(importRows())
+		mock.ExpectRollback()
+
+		_, err := processor.UploadFile(ctx, "orders.csv", strings.NewReader("id\n3"), domain.ExtCSV,
+			models.UploadOptions{IdempotencyKey: "retry-1"})
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("same file appended again returns original result", func(t *testing.T) {
+		mock.ExpectQuery(`FROM imports WHERE table_name = \$1 AND checksum = \$2 AND status = 'success'`).
+			WithArgs("orders", checksum, sqlmock.AnyArg()).
+			WillReturnRows(importRows())
+
+		result, err := processor.UploadFile(ctx, "orders.csv", strings.NewReader(content), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.True(t, result.Replayed)
+		assert.Equal(t, int64(5), result.ImportID)
+	})
+
+	// load is stopped at its transaction, no checksum lookup before it
+	expectLoad := func() {
+		mock.ExpectBegin().WillReturnError(errors.New("db is down"))
+		mock.ExpectQuery(`INSERT INTO imports`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	}
+
+	t.Run("forced append loads the same file again", func(t *testing.T) {
+		expectLoad()
+
+		_, err := processor.UploadFile(ctx, "orders.csv", strings.NewReader(content), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend, Force: true})
+
+		assert.ErrorContains(t, err, "db is down")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("append with new key is checked only by key", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+			WithArgs("retry-2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`FROM imports WHERE idempotency_key = \$1`).
+			WithArgs("retry-2", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		expectLoad()
+		mock.ExpectRollback()
+
+		_, err := processor.UploadFile(ctx, "orders.csv", strings.NewReader(content), domain.ExtCSV,
+			models.UploadOptions{Mode: models.UploadModeAppend, IdempotencyKey: "retry-2"})
+
+		assert.ErrorContains(t, err, "db is down")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestProcessorService_UploadFileConcurrentKey(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	require.NoError(t, database.NewSQLiteMigrator(db, migrations.SQLite(), log).Up(context.Background()))
+	repo := sqlite.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{IdempotencyWindow: time.Hour}, log).Processor()
+	ctx := context.Background()
+
+	// file is big enough for requests to overlap
+	const requests, rows = 8, 5000
+	var content strings.Builder
+	content.WriteString("id")
+	for i := range rows {
+		content.WriteString("\n" + strconv.Itoa(i))
+	}
+
+	results := make([]models.ImportResult, requests)
+	errs := make([]error, requests)
+
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = processor.UploadFile(ctx, "orders.csv", strings.NewReader(content.String()), domain.ExtCSV,
+				models.UploadOptions{Mode: models.UploadModeAppend, IdempotencyKey: "retry-1"})
+		}()
+	}
+	wg.Wait()
+
+	// file is loaded once, other requests replay its import
+	replayed := 0
+	for i := range requests {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0].ImportID, results[i].ImportID)
+		if results[i].Replayed {
+			replayed++
+		}
+	}
+	assert.Equal(t, requests-1, replayed)
+
+	imports, err := repo.Import().List(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, imports, 1)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM orders;`).Scan(&count))
+	assert.Equal(t, rows, count)
+}
+
+func TestProcessorService_Convert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	cfg := config.AnalyzerCfg{BoolTrue: []string{"yes"}, BoolFalse: []string{"no"}}
+	processor := service.NewService(repo, cfg, config.ImportCfg{}, log).Processor()
+
+	csvData := "id,name,active\n1,O'Brien,yes\n,Ann,no"
+
+	var script strings.Builder
+	result, err := processor.Convert(context.Background(), "clients.csv", strings.NewReader(csvData), domain.ExtCSV,
+		models.UploadOptions{Schema: "staging"}, &script)
+	require.NoError(t, err)
+
+	// offline --> no queries
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "staging.clients", result.Table.QualifiedName())
+	assert.Equal(t, 2, result.Rows)
+
+	sql := script.String()
+	assert.True(t, strings.HasPrefix(sql, "BEGIN;"))
+	assert.Contains(t, sql, `CREATE TABLE IF NOT EXISTS "staging"."clients" ("id" BIGINT, "name" TEXT, "active" BOOLEAN);`)
+	assert.Contains(t, sql, `INSERT INTO "staging"."clients" ("id", "name", "active") VALUES`+"\n('1', 'O''Brien', 'true'),\n(NULL, 'Ann', 'false');")
+	assert.True(t, strings.HasSuffix(sql, "COMMIT;\n"))
+
+	_, err = processor.Convert(context.Background(), "clients.csv", strings.NewReader(csvData), domain.ExtCSV,
+		models.UploadOptions{Schema: "pg_catalog"}, &script)
+	assert.ErrorIs(t, err, domain.ErrInvalidSchema)
+
+	// other dialect: bare numbers and booleans, no transaction
+	script.Reset()
+	_, err = processor.Convert(context.Background(), "clients.csv", strings.NewReader(csvData), domain.ExtCSV,
+		models.UploadOptions{Dialect: models.DialectClickHouse}, &script)
+	require.NoError(t, err)
+
+	sql = script.String()
+	assert.True(t, strings.HasPrefix(sql, "DROP TABLE IF EXISTS `clients`;"))
+	assert.Contains(t, sql, "INSERT INTO `clients` (`id`, `name`, `active`) VALUES\n(1, 'O\\'Brien', true),\n(NULL, 'Ann', false);")
+	assert.NotContains(t, sql, "COMMIT")
+
+	_, err = processor.Convert(context.Background(), "clients.csv", strings.NewReader(csvData), domain.ExtCSV,
+		models.UploadOptions{Dialect: "oracle"}, &script)
+	assert.ErrorIs(t, err, domain.ErrUnsupportedDialect)
+	// rows failed conversion: left out within error budget, abort strict mode
+	tolerant := config.AnalyzerCfg{TolerancePercent: 30}
+	csvData = "id,amount\n1,10\n2,20\n3,N/A\n4,40"
+
+	result, err = service.NewService(repo, tolerant, config.ImportCfg{MaxErrors: 1}, log).Processor().
+		Convert(context.Background(), "payments.csv", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{}, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Rows)
+	assert.Equal(t, 1, result.Rejected)
+
+	_, err = service.NewService(repo, tolerant, config.ImportCfg{}, log).Processor().
+		Convert(context.Background(), "payments.csv", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{}, io.Discard)
+	assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+}
+
+func TestProcessorService_ConvertSQLite(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	cfg := config.AnalyzerCfg{BoolTrue: []string{"yes"}, BoolFalse: []string{"no"}}
+	processor := service.NewService(repo, cfg, config.ImportCfg{}, log).Processor()
+
+	// workbook: two sheets with data and empty one
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetRow("Sheet1", "A1", &[]any{"id", "active"}))
+	require.NoError(t, book.SetSheetRow("Sheet1", "A2", &[]any{1, "yes"}))
+	_, err = book.NewSheet("Продажи 2026")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("Продажи 2026", "A1", &[]any{"amount"}))
+	require.NoError(t, book.SetSheetRow("Продажи 2026", "A2", &[]any{2.5}))
+	_, err = book.NewSheet("Empty")
+	require.NoError(t, err)
+
+	var xlsx bytes.Buffer
+	require.NoError(t, book.Write(&xlsx))
+
+	var out bytes.Buffer
+	results, err := processor.ConvertSQLite(context.Background(), "report.xlsx", &xlsx, domain.ExtXLSX,
+		models.UploadOptions{Table: "q1"}, &out)
+	require.NoError(t, err)
+
+	// offline --> no queries
+	assert.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, results, 2)
+	assert.Equal(t, "q1_sheet1", results[0].Table.Name)
+	assert.Equal(t, "q1_prodazhi_2026", results[1].Table.Name)
+	assert.Equal(t, 1, results[1].Rows)
+
+	path := filepath.Join(t.TempDir(), "report.sqlite")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+	file, err := database.OpenSQLite(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var active int
+	require.NoError(t, file.QueryRow(`SELECT active FROM q1_sheet1 WHERE id = 1;`).Scan(&active))
+	assert.Equal(t, 1, active)
+
+	var amount float64
+	require.NoError(t, file.QueryRow(`SELECT amount FROM q1_prodazhi_2026;`).Scan(&amount))
+	assert.Equal(t, 2.5, amount)
+
+	// CSV is one table named as file
+	out.Reset()
+	results, err = processor.ConvertSQLite(context.Background(), "clients.csv", strings.NewReader("id\n1\n2"), domain.ExtCSV,
+		models.UploadOptions{}, &out)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "clients", results[0].Table.Name)
+
+	_, err = processor.ConvertSQLite(context.Background(), "clients.csv", strings.NewReader(""), domain.ExtCSV,
+		models.UploadOptions{}, &out)
+	assert.ErrorIs(t, err, domain.ErrEmptyData)
+
+	// rows failed conversion: saved in <table>_rejects within error budget, abort strict mode
+	tolerant := config.AnalyzerCfg{TolerancePercent: 30}
+	csvData := "id,amount\n1,10\n2,20\n3,N/A\n4,40"
+
+	out.Reset()
+	results, err = service.NewService(repo, tolerant, config.ImportCfg{MaxErrors: 1}, log).Processor().
+		ConvertSQLite(context.Background(), "payments.csv", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{}, &out)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 3, results[0].Rows)
+	assert.Equal(t, 1, results[0].Rejected)
+
+	out.Reset()
+	_, err = service.NewService(repo, tolerant, config.ImportCfg{}, log).Processor().
+		ConvertSQLite(context.Background(), "payments.csv", strings.NewReader(csvData), domain.ExtCSV, models.UploadOptions{}, &out)
+	assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+	assert.Zero(t, out.Len())
+}
+
+func TestProcessorService_UploadUnion(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	require.NoError(t, database.NewSQLiteMigrator(db, migrations.SQLite(), log).Up(context.Background()))
+	repo := sqlite.NewRepository(db, log)
+	processor := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+	ctx := context.Background()
+
+	// archive: month of sales, macOS metadata and file which is not a table
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"sales/sales_2026_03.csv":            "id,Region\n4,south",
+		"__MACOSX/sales/._sales_2026_03.csv": "junk",
+		"readme.txt":                         "not a table",
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	files := map[string][]byte{
+		"sales_2026_01.csv": []byte("ID,Amount\n1,10\n2,20"),
+		"sales_2026_02.csv": []byte("id,amount,region\n3,12.5,north"),
+		"sales_2026_03.zip": archive.Bytes(),
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(files[name])), nil
+	}
+	names := []string{"sales_2026_01.csv", "sales_2026_02.csv", "sales_2026_03.zip"}
+
+	t.Run("schemas are merged and absent columns are NULL", func(t *testing.T) {
+		opts := models.UploadOptions{Table: "sales", SourceColumn: "source_file"}
+
+		result, err := processor.UploadUnion(ctx, names, open, opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, result.Rows)
+		require.Len(t, result.Table.Columns, 4)
+		assert.Equal(t, "ID", result.Table.Columns[0].Name, "first spelling is kept")
+		assert.Equal(t, models.DataTypeInteger, result.Table.Columns[0].Type)
+		assert.Equal(t, models.DataTypeFloat, result.Table.Columns[1].Type, "integer and float --> float")
+		assert.Equal(t, 2, result.Table.Columns[2].Profile.NullCount, "rows of file without region")
+
+		require.Len(t, result.Files, 3)
+		assert.Equal(t, []string{"region"}, result.Files[0].Missing)
+		assert.Equal(t, "sales/sales_2026_03.csv", result.Files[2].Name)
+		assert.Equal(t, []string{"Amount"}, result.Files[2].Missing)
+
+		page, err := repo.Table().Rows(ctx, "sales", models.RowsQuery{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{
+			{int64(1), 10.0, nil, "sales_2026_01.csv"},
+			{int64(2), 20.0, nil, "sales_2026_01.csv"},
+			{int64(3), 12.5, "north", "sales_2026_02.csv"},
+			{int64(4), nil, "south", "sales/sales_2026_03.csv"},
+		}, page.Rows)
+
+		imports, err := repo.Import().List(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "sales_2026_01.csv, sales_2026_02.csv, sales_2026_03.zip", imports[0].Filename)
+	})
+
+	t.Run("source column must not clash with file columns", func(t *testing.T) {
+		opts := models.UploadOptions{Table: "sales", SourceColumn: "REGION"}
+
+		_, err := processor.UploadUnion(ctx, names, open, opts)
+		assert.ErrorIs(t, err, domain.ErrInvalidColumnName)
+	})
+
+	t.Run("uncompressed size of archive is limited", func(t *testing.T) {
+		// CSV of archive has 17 bytes
+		opts := models.UploadOptions{Table: "sales_limited"}
+
+		limited := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{MaxUnzippedSize: 16}, log).Processor()
+		_, err := limited.UploadUnion(ctx, names, open, opts)
+		assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+
+		limited = service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{MaxUnzippedSize: 17}, log).Processor()
+		result, err := limited.UploadUnion(ctx, names, open, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Rows)
+	})
+
+	t.Run("error budget is shared by all files", func(t *testing.T) {
+		// every file has one row failed conversion
+		files := map[string][]byte{
+			"payments_01.csv": []byte("id,amount\n1,10\n2,20\n3,30\n4,N/A"),
+			"payments_02.csv": []byte("id,amount\n5,50\n6,N/A\n7,70\n8,80"),
+		}
+		open := func(name string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(files[name])), nil
+		}
+		names := []string{"payments_01.csv", "payments_02.csv"}
+		tolerant := config.AnalyzerCfg{TolerancePercent: 30}
+		opts := models.UploadOptions{Table: "payments"}
+
+		_, err := service.NewService(repo, tolerant, config.ImportCfg{MaxErrors: 1}, log).Processor().
+			UploadUnion(ctx, names, open, opts)
+		assert.ErrorIs(t, err, domain.ErrTooManyErrors)
+
+		result, err := service.NewService(repo, tolerant, config.ImportCfg{MaxErrors: 2}, log).Processor().
+			UploadUnion(ctx, names, open, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 6, result.Rows)
+		require.Len(t, result.Rejects, 2)
+		assert.Equal(t, 4, result.Rejects[0].Row)
+		assert.Equal(t, 6, result.Rejects[1].Row, "rows are numbered through all files")
+	})
+}
+
+func TestBatchService_ParseManifest(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	batches := service.NewService(nil, config.AnalyzerCfg{}, config.ImportCfg{BatchParallelism: 4}, log).Batches()
+
+	manifest, err := batches.ParseManifest(strings.NewReader(`
+parallelism: 10
+defaults:
+  schema: reporting
+  mode: append
+files:
+  - file: data/regions.csv
+    mode: replace
+  - name: sales
+    file: data/sales.xlsx
+    table: sales_{yyyy}
+    lineage: true
+    depends_on: [data/regions.csv]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, manifest.Parallelism)
+	require.Len(t, manifest.Steps, 2)
+	assert.Equal(t, "data/regions.csv", manifest.Steps[0].Name)
+	assert.Equal(t, models.UploadOptions{Mode: models.UploadModeReplace, Schema: "reporting"}, manifest.Steps[0].Options)
+	assert.Equal(t, models.UploadOptions{Mode: models.UploadModeAppend, Schema: "reporting", Table: "sales_{yyyy}", Lineage: true},
+		manifest.Steps[1].Options)
+	assert.Equal(t, []string{"data/regions.csv"}, manifest.Steps[1].DependsOn)
+
+	for name, manifest := range map[string]string{
+		"no files":           "parallelism: 2",
+		"unknown field":      "files:\n  - file: a.csv\n    sheet: 2",
+		"invalid mode":       "files:\n  - file: a.csv\n    mode: merge",
+		"duplicate name":     "files:\n  - file: a.csv\n  - file: a.csv",
+		"unknown dependency": "files:\n  - file: a.csv\n    depends_on: [b.csv]",
+		"dependency cycle":   "files:\n  - file: a.csv\n    depends_on: [b.csv]\n  - file: b.csv\n    depends_on: [a.csv]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := batches.ParseManifest(strings.NewReader(manifest))
+			assert.ErrorIs(t, err, domain.ErrInvalidManifest)
+		})
+	}
+}
+
+func TestBatchService_FilesManifest(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	batches := service.NewService(nil, config.AnalyzerCfg{}, config.ImportCfg{BatchParallelism: 4}, log).Batches()
+
+	opts := models.UploadOptions{Mode: models.UploadModeAppend, IdempotencyKey: "run-1"}
+	manifest, err := batches.FilesManifest([]string{"a.csv", "b.csv"}, opts, 10, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, manifest.Parallelism, "capped by config")
+	assert.True(t, manifest.AllOrNothing)
+	require.Len(t, manifest.Steps, 2)
+	assert.Equal(t, "b.csv", manifest.Steps[1].Name)
+	assert.Equal(t, models.UploadModeAppend, manifest.Steps[1].Options.Mode)
+	assert.Equal(t, "run-1/b.csv", manifest.Steps[1].Options.IdempotencyKey)
+	assert.Empty(t, manifest.Steps[1].DependsOn)
+
+	manifest, err = batches.FilesManifest([]string{"a.csv"}, models.UploadOptions{}, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, manifest.Parallelism, "default of config")
+	assert.Empty(t, manifest.Steps[0].Options.IdempotencyKey)
+
+	// files would be loaded concurrently to one table
+	_, err = batches.FilesManifest([]string{"Sales.csv", "sales.xlsx"}, models.UploadOptions{}, 0, false)
+	assert.ErrorIs(t, err, domain.ErrInvalidManifest)
+}
+
+func TestBatchService_Run(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := postgres.NewRepository(db, log)
+	batches := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{BatchParallelism: 2}, log).Batches()
+	ctx := context.Background()
+
+	files := map[string]string{"regions.csv": "id\n1", "sales.txt": "not a table"}
+	open := func(name string) (io.ReadCloser, error) {
+		content, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+
+	steps := []models.BatchStep{
+		{Name: "regions", File: "regions.csv"},
+		{Name: "report", File: "report.csv", DependsOn: []string{"sales"}},
+		{Name: "sales", File: "sales.txt", DependsOn: []string{"regions"}},
+	}
+
+	t.Run("failed step rolls back all-or-nothing batch", func(t *testing.T) {
+		mock.ExpectBegin()
+
+		// regions: repository transactions are savepoints of batch transaction
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "regions" \("id" BIGINT\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(`INSERT INTO "regions"`)
+		mock.ExpectExec(`INSERT INTO "regions"`).WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT repo_tx_1;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectProfileSave(mock, "regions")
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WithArgs("regions.csv", "regions", sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1,
+				0, sqlmock.AnyArg(), sqlmock.AnyArg(), "success", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		expectRegister(mock, "regions")
+
+		// sales: unsupported file
+		mock.ExpectQuery(`INSERT INTO imports .* RETURNING id;`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		mock.ExpectRollback()
+
+		report, err := batches.Run(ctx, models.BatchManifest{AllOrNothing: true, Steps: steps}, open)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, models.BatchStatusFailed, report.Status)
+		require.Len(t, report.Steps, 3)
+		assert.Equal(t, models.BatchStatusRolledBack, report.Steps[0].Status)
+		assert.Equal(t, 1, report.Steps[0].Result.Rows)
+		assert.Equal(t, models.BatchStatusSkipped, report.Steps[1].Status)
+		assert.Equal(t, models.BatchStatusFailed, report.Steps[2].Status)
+		assert.Contains(t, report.Steps[2].Error, domain.ErrUnsupportedExtension.Error())
+	})
+
+	t.Run("dependents of failed step are skipped", func(t *testing.T) {
+		manifest := models.BatchManifest{Parallelism: 2, Steps: []models.BatchStep{
+			{Name: "customers", File: "customers.csv"},
+			{Name: "orders", File: "orders.csv", DependsOn: []string{"customers"}},
+		}}
+
+		report, err := batches.Run(ctx, manifest, open)
+		require.NoError(t, err)
+
+		assert.Equal(t, models.BatchStatusFailed, report.Status)
+		assert.Equal(t, models.BatchStatusFailed, report.Steps[0].Status)
+		assert.Equal(t, models.BatchStatusSkipped, report.Steps[1].Status)
+		assert.Contains(t, report.Steps[1].Error, `dependency "customers"`)
+	})
+}
+
+func TestExportService_Export(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	svc := service.NewService(postgres.NewRepository(db, log), config.AnalyzerCfg{}, config.ImportCfg{}, log)
+	exporter := svc.Export()
+	ctx := context.Background()
+
+	paidAt := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	expectExport := func() {
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "orders").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("id", "bigint").
+				AddRow("comment", "text").
+				AddRow("amount", "numeric").
+				AddRow("paid", "boolean").
+				AddRow("paid_at", "timestamp without time zone"))
+		mock.ExpectQuery(`SELECT "id", "comment", "amount", "paid", "paid_at" FROM "orders";`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "comment", "amount", "paid", "paid_at"}).
+				AddRow(int64(1), `=HYPERLINK("http://evil")`, []byte("-12.50"), true, paidAt).
+				AddRow(int64(2), nil, []byte("3"), false, nil))
+	}
+
+	t.Run("csv escapes formulas in text cells only", func(t *testing.T) {
+		expectExport()
+
+		var buf bytes.Buffer
+		count, err := exporter.Export(ctx, "orders", domain.ExtCSV, &buf)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, "id,comment,amount,paid,paid_at\n"+
+			`1,"'=HYPERLINK(""http://evil"")",-12.50,true,2024-03-15 10:30:00`+"\n"+
+			"2,,3,false,\n", buf.String())
+	})
+
+	t.Run("csv escapes formulas in header", func(t *testing.T) {
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "orders").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("id", "bigint").
+				AddRow(`=cmd|' /C calc'!A0`, "text"))
+		mock.ExpectQuery(`SELECT "id", "=cmd\|' /C calc'!A0" FROM "orders";`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "evil"}).AddRow(int64(1), "ok"))
+
+		var buf bytes.Buffer
+		_, err := exporter.Export(ctx, "orders", domain.ExtCSV, &buf)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		assert.Equal(t, "id,'=cmd|' /C calc'!A0\n1,ok\n", buf.String())
+	})
+
+	t.Run("xlsx keeps native cell types", func(t *testing.T) {
+		expectExport()
+
+		var buf bytes.Buffer
+		_, err := exporter.Export(ctx, "orders", domain.ExtXLSX, &buf)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		f, err := excelize.OpenReader(&buf)
+		require.NoError(t, err)
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+
+		// number cells have no type attribute
+		types := map[string]excelize.CellType{"A2": excelize.CellTypeUnset, "C2": excelize.CellTypeUnset, "D2": excelize.CellTypeBool}
+		for cell, want := range types {
+			got, err := f.GetCellType(sheet, cell)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, cell)
+		}
+
+		amount, err := f.GetCellValue(sheet, "C2", excelize.Options{RawCellValue: true})
+		require.NoError(t, err)
+		assert.Equal(t, "-12.5", amount)
+
+		comment, err := f.GetCellValue(sheet, "B2")
+		require.NoError(t, err)
+		assert.Equal(t, `=HYPERLINK("http://evil")`, comment)
+		formula, err := f.GetCellFormula(sheet, "B2")
+		require.NoError(t, err)
+		assert.Empty(t, formula)
+
+		paid, err := f.GetCellValue(sheet, "E2")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-03-15 10:30:00", paid)
+	})
+
+	t.Run("xlsx writes timestamptz in UTC", func(t *testing.T) {
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "orders").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+				AddRow("created_at", "timestamp with time zone"))
+		mock.ExpectQuery(`SELECT "created_at" FROM "orders";`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).
+				AddRow(time.Date(2024, 3, 15, 13, 30, 0, 0, time.FixedZone("MSK", 3*60*60))))
+
+		var buf bytes.Buffer
+		_, err := exporter.Export(ctx, "orders", domain.ExtXLSX, &buf)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		f, err := excelize.OpenReader(&buf)
+		require.NoError(t, err)
+		defer f.Close()
+
+		created, err := f.GetCellValue(f.GetSheetName(0), "A2")
+		require.NoError(t, err)
+		assert.Equal(t, "2024-03-15 10:30:00", created)
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		mock.ExpectQuery(`FROM information_schema.columns`).
+			WithArgs("", "missing").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}))
+
+		var buf bytes.Buffer
+		_, err := exporter.Export(ctx, "missing", domain.ExtCSV, &buf)
+		assert.ErrorIs(t, err, domain.ErrTableNotFound)
+		assert.Zero(t, buf.Len())
+	})
+
+	t.Run("schema not allowed", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := exporter.Export(ctx, "finance.sales", domain.ExtCSV, &buf)
+		assert.ErrorIs(t, err, domain.ErrSchemaNotAllowed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("service tables", func(t *testing.T) {
+		for _, name := range []string{"imports", "Managed_Tables", "table_profiles_rejects"} {
+			var buf bytes.Buffer
+			_, err := exporter.Export(ctx, name, domain.ExtCSV, &buf)
+			assert.ErrorIs(t, err, domain.ErrInvalidTableName, name)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestProcessorService_UploadFileReservedName(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	require.NoError(t, database.NewSQLiteMigrator(db, migrations.SQLite(), log).Up(context.Background()))
+	repo := sqlite.NewRepository(db, log)
+	svc := service.NewService(repo, config.AnalyzerCfg{}, config.ImportCfg{}, log)
+	ctx := context.Background()
+
+	cases := []struct {
+		filename string
+		table    string
+	}{
+		{filename: "imports.csv"},
+		{filename: "Table Profiles.csv"},
+		{filename: "report.csv", table: "schema_migrations"},
+		{filename: "report.csv", table: "managed_tables_rejects"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.filename+" "+tc.table+" is rejected", func(t *testing.T) {
+			_, err := svc.Processor().UploadFile(ctx, tc.filename, strings.NewReader("id\n1"), domain.ExtCSV,
+				models.UploadOptions{Table: tc.table})
+			assert.ErrorIs(t, err, domain.ErrInvalidTableName)
+		})
+	}
+
+	t.Run("service tables are intact", func(t *testing.T) {
+		imports, err := repo.Import().List(ctx, 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, imports, len(cases), "failed imports are recorded")
+	})
+
+	t.Run("table can not be renamed to service table", func(t *testing.T) {
+		_, err := svc.Processor().UploadFile(ctx, "report.csv", strings.NewReader("id\n1"), domain.ExtCSV,
+			models.UploadOptions{})
+		require.NoError(t, err)
+
+		err = svc.Tables().Rename(ctx, "report", "imports")
+		assert.ErrorIs(t, err, domain.ErrInvalidTableName)
 	})
 }
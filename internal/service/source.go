@@ -0,0 +1,37 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// sourceReader - reader of uploaded file which counts bytes and computes SHA-256 while parsing
+type sourceReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+}
+
+func newSourceReader(r io.Reader) *sourceReader {
+	return &sourceReader{r: r, hash: sha256.New()}
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.size += int64(n)
+	s.hash.Write(p[:n])
+	return n, err
+}
+
+// checksum - return checksum of bytes read so far
+func (s *sourceReader) checksum() string {
+	return hex.EncodeToString(s.hash.Sum(nil))
+}
+
+// finish - read the rest of file (not consumed by parser) and return checksum and size
+func (s *sourceReader) finish() (string, int64) {
+	_, _ = io.Copy(io.Discard, s)
+	return s.checksum(), s.size
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+type tableService struct {
+	repo domain.Repository
+	log  *slog.Logger
+}
+
+func newTableService(repo domain.Repository, log *slog.Logger) domain.TableService {
+	return &tableService{repo: repo, log: log}
+}
+
+// List - return tables created by service (sorted by name)
+func (s *tableService) List(ctx context.Context, filter models.TableFilter) ([]models.TableInfo, error) {
+	const op = "service.tables.List"
+
+	tables, err := s.repo.Table().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tables, nil
+}
+
+// Describe - return columns, row count and size of table created by service
+func (s *tableService) Describe(ctx context.Context, name string) (models.TableInfo, error) {
+	const op = "service.tables.Describe"
+
+	info, err := s.repo.Table().Describe(ctx, name)
+	if err != nil {
+		return models.TableInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return info, nil
+}
+
+// Rename - rename table created by service, new name must be already sanitized
+func (s *tableService) Rename(ctx context.Context, name, newName string) error {
+	const op = "service.tables.Rename"
+	log := s.log.With("op", op)
+
+	if !validTableName(newName) {
+		return fmt.Errorf("%s: %q: %w", op, newName, domain.ErrInvalidTableName)
+	}
+	if newName == name {
+		return nil
+	}
+
+	if err := s.repo.Table().Rename(ctx, name, newName); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("table renamed", slog.String("table", name), slog.String("new_name", newName))
+	return nil
+}
+
+// Drop - drop table created by service with its rejects and profile
+func (s *tableService) Drop(ctx context.Context, name string) error {
+	const op = "service.tables.Drop"
+	log := s.log.With("op", op)
+
+	if err := s.repo.Table().Drop(ctx, name); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("table dropped", slog.String("table", name))
+	return nil
+}
+
+// Rows - return page of rows of table created by service
+func (s *tableService) Rows(ctx context.Context, name string, query models.RowsQuery) (models.RowsPage, error) {
+	const op = "service.tables.Rows"
+
+	page, err := s.repo.Table().Rows(ctx, name, query)
+	if err != nil {
+		return models.RowsPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return page, nil
+}
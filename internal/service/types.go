@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+var (
+	uuidRegexp        = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	isoIntervalRegexp = regexp.MustCompile(`^P(?:\d+Y)?(?:\d+M)?(?:\d+W)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+(?:\.\d+)?S)?)?$`)
+)
+
+// isValidValue - check that trimmed non-empty value can be stored in column of type t
+func isValidValue(bools boolVocabulary, val string, t models.DataType) bool {
+	switch t {
+	case models.DataTypeInteger:
+		_, err := strconv.ParseInt(val, 10, 64)
+		return err == nil
+	case models.DataTypeFloat:
+		_, err := strconv.ParseFloat(val, 64)
+		return err == nil
+	case models.DataTypeBoolean:
+		_, ok := bools.lookup(val)
+		return ok
+	case models.DataTypeUUID:
+		return isUUID(val)
+	case models.DataTypeJSON:
+		return isJSON(val)
+	case models.DataTypeInet:
+		return isInet(val)
+	case models.DataTypeCIDR:
+		return isCIDR(val)
+	case models.DataTypeInterval:
+		return isInterval(val)
+	default:
+		return true
+	}
+}
+
+// isNumericType - check Integer or Float
+func isNumericType(t models.DataType) bool {
+	return t == models.DataTypeInteger || t == models.DataTypeFloat
+}
+
+// isUUID - check canonical UUID form (8-4-4-4-12)
+func isUUID(val string) bool {
+	return uuidRegexp.MatchString(val)
+}
+
+// isJSON - check JSON object or array
+func isJSON(val string) bool {
+	if !strings.HasPrefix(val, "{") && !strings.HasPrefix(val, "[") {
+		return false
+	}
+	return json.Valid([]byte(val))
+}
+
+// isInet - check IP address with optional netmask (192.168.0.1, 10.0.0.1/8, ::1)
+func isInet(val string) bool {
+	if strings.Contains(val, "/") {
+		_, _, err := net.ParseCIDR(val)
+		return err == nil
+	}
+	return net.ParseIP(val) != nil
+}
+
+// isCIDR - check network address without host bits (10.0.0.0/8)
+func isCIDR(val string) bool {
+	ip, ipNet, err := net.ParseCIDR(val)
+	if err != nil {
+		return false
+	}
+	return ip.Equal(ipNet.IP)
+}
+
+// isInterval - check ISO 8601 duration (P3D, PT1H30M) or Go duration (1h30m)
+func isInterval(val string) bool {
+	if isISOInterval(val) {
+		return true
+	}
+	_, err := time.ParseDuration(val)
+	return err == nil
+}
+
+func isISOInterval(val string) bool {
+	return val != "P" && !strings.HasSuffix(val, "T") && isoIntervalRegexp.MatchString(val)
+}
+
+// formatInterval - convert Go duration (1h30m) to Postgres interval (01:30:00)
+func formatInterval(val string) string {
+	if isISOInterval(val) {
+		return val
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return val
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	sec := d / time.Second
+	d -= sec * time.Second
+	us := d / time.Microsecond
+
+	return fmt.Sprintf("%s%02d:%02d:%02d.%06d", sign, h, m, sec, us)
+}
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+// sourceColumnRegexp - names of source column accepted as is
+var sourceColumnRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// unionSource - parsed file of union
+type unionSource struct {
+	name     string
+	sheet    models.Sheet
+	checksum string
+	size     int64
+}
+
+// UploadUnion - load several files into one table (schemas are merged) and record it in imports catalog as one import
+func (s *processorService) UploadUnion(ctx context.Context, files []string, open domain.FileOpener, opts models.UploadOptions) (models.UnionResult, error) {
+	const op = "service.processor.UploadUnion"
+
+	started := time.Now()
+
+	if len(files) == 0 {
+		return models.UnionResult{}, fmt.Errorf("%s: %w", op, domain.ErrEmptyData)
+	}
+
+	// target schema and source column
+	if err := s.checkSchema(opts.Schema); err != nil {
+		return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts.SourceColumn != "" && (!sourceColumnRegexp.MatchString(opts.SourceColumn) || len(opts.SourceColumn) > maxIdentifierLen) {
+		return models.UnionResult{}, fmt.Errorf("%s: %q: %w", op, opts.SourceColumn, domain.ErrInvalidColumnName)
+	}
+
+	// parsing (archives are expanded)
+	sources, err := s.readUnionSources(ctx, files, open)
+	if err != nil {
+		return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var result models.UnionResult
+	err = s.withKey(ctx, opts.IdempotencyKey, func(ctx context.Context) error {
+		var err error
+		result, err = s.uploadUnion(ctx, files, sources, opts, started)
+		return err
+	})
+	return result, err
+}
+
+// uploadUnion - UploadUnion of parsed sources under lock of idempotency key
+func (s *processorService) uploadUnion(ctx context.Context, files []string, sources []unionSource, opts models.UploadOptions, started time.Time) (models.UnionResult, error) {
+	const op = "service.processor.UploadUnion"
+	log := s.log.With("op", op)
+
+	checksum, size := unionChecksum(sources)
+
+	// go to DB (repeated request with the same key)
+	if opts.IdempotencyKey != "" && s.idempotencyWindow > 0 {
+		original, err := s.repo.Import().FindByIdempotencyKey(ctx, opts.IdempotencyKey, started.Add(-s.idempotencyWindow))
+		switch {
+		case errors.Is(err, domain.ErrImportNotFound):
+			// first request with the key
+		case err != nil:
+			return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+		case checksum != original.Checksum:
+			return models.UnionResult{}, fmt.Errorf("%s: %q: %w", op, opts.IdempotencyKey, domain.ErrIdempotencyKeyReused)
+		default:
+			log.Info("union replayed", slog.String("key", opts.IdempotencyKey), slog.Int64("import_id", original.ID))
+			return models.UnionResult{ImportResult: replayResult(original)}, nil
+		}
+	}
+
+	// go to DB (reserve import id, lineage columns refer to it)
+	var importID int64
+	if opts.Lineage {
+		id, err := s.repo.Import().NextID(ctx)
+		if err != nil {
+			return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		importID = id
+	}
+
+	result, err := s.union(ctx, files[0], sources, opts, importID)
+	if result.ImportID != 0 {
+		importID = result.ImportID
+	}
+
+	// table name is resolved only on success, otherwise requested one is recorded
+	target := models.Table{Schema: opts.Schema, Name: sanitizeTableName(files[0])}
+	if opts.Table != "" {
+		target.Name = opts.Table
+	}
+	if result.Table.Name != "" {
+		target = result.Table
+	}
+
+	imp := models.Import{
+		ID:             importID,
+		Filename:       strings.Join(files, ", "),
+		TableName:      target.QualifiedName(),
+		Checksum:       checksum,
+		Size:           size,
+		Rows:           result.Rows,
+		Columns:        len(result.Table.Columns),
+		Rejected:       result.Rejected,
+		Schema:         result.Table,
+		Duration:       time.Since(started),
+		Status:         models.ImportStatusSuccess,
+		IdempotencyKey: opts.IdempotencyKey,
+	}
+	if err != nil {
+		imp.Status = models.ImportStatusFailed
+		imp.Error = err.Error()
+	}
+
+	// go to DB (record import), request may be canceled --> record anyway
+	id, recErr := s.repo.Import().Create(context.WithoutCancel(ctx), imp)
+	if recErr != nil {
+		log.Warn("failed to record import", slog.Any("err", recErr))
+	}
+	result.ImportID = id
+
+	if err != nil {
+		return models.UnionResult{}, err
+	}
+
+	// go to DB (mark table as managed by service)
+	if err := s.repo.Table().Register(context.WithoutCancel(ctx), result.Table.QualifiedName(), id); err != nil {
+		log.Warn("failed to register table", slog.Any("err", err))
+	}
+
+	return result, nil
+}
+
+// union - analyze files, merge their schemas, create table and save data of every file in one transaction
+// (filename - first uploaded file, default table name is derived from it)
+func (s *processorService) union(ctx context.Context, filename string, sources []unionSource, opts models.UploadOptions, importID int64) (models.UnionResult, error) {
+	const op = "service.processor.UploadUnion"
+	log := s.log.With("op", op)
+
+	// table name (version suffix is chosen at creation)
+	tableName, err := requestedTableName(filename, opts)
+	if err != nil {
+		return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// analyzing every file and merging schemas
+	tables := make([]models.Table, len(sources))
+	rows := make([]int, len(sources))
+	for i, src := range sources {
+		table, err := s.analyzer.Analyze(ctx, tableName, src.sheet.Rows)
+		if err != nil {
+			return models.UnionResult{}, fmt.Errorf("%s: analysis of %s failed: %w", op, src.name, err)
+		}
+		tables[i] = table
+		rows[i] = len(src.sheet.Rows) - 1
+	}
+
+	table, mapping := mergeSchemas(tables, rows)
+	table.Schema = opts.Schema
+
+	if opts.SourceColumn != "" {
+		for _, col := range table.Columns {
+			if strings.EqualFold(col.Name, opts.SourceColumn) {
+				return models.UnionResult{}, fmt.Errorf("%s: %q is a column of file: %w", op, opts.SourceColumn, domain.ErrInvalidColumnName)
+			}
+		}
+		table.Columns = append(table.Columns, models.Column{Name: opts.SourceColumn, Type: models.DataTypeString, Confidence: 1})
+	}
+
+	loadedAt := time.Now()
+	if opts.Lineage {
+		table.Lineage = &models.Lineage{ImportID: importID, SourceFile: sources[0].name, LoadedAt: loadedAt}
+	}
+
+	result := models.UnionResult{ImportResult: models.ImportResult{Table: table}}
+
+	// go to DB (table and data of all files are one transaction, failed file leaves no rows)
+	err = s.repo.Atomic(ctx, func(ctx context.Context) error {
+		changes, err := s.prepareTable(ctx, &table, opts)
+		if err != nil {
+			return err
+		}
+		result.Table = table
+		result.Changes = changes
+
+		// converting every file first, error budget is shared by all files
+		parts := make([]models.Table, len(sources))
+		converted := make([]convertedData, len(sources))
+		missing := make([][]string, len(sources))
+		var total, failed int
+		for i, src := range sources {
+			part, data, miss := unionPart(table, mapping[i], src, opts.SourceColumn)
+			if opts.Lineage {
+				part.Lineage = &models.Lineage{ImportID: importID, SourceFile: src.name, SourceSheet: src.sheet.Name, LoadedAt: loadedAt}
+			}
+
+			rows, numbers, rejects := s.converter.Convert(part, data)
+			parts[i], missing[i] = part, miss
+			converted[i] = convertedData{rows: rows, numbers: numbers, rejects: rejects, total: len(data)}
+			total += len(data)
+			failed += len(rejects)
+		}
+
+		budget := s.errorBudget(total)
+		if failed > budget {
+			return fmt.Errorf("%d rows failed conversion: %w", failed, domain.ErrTooManyErrors)
+		}
+		budget -= failed
+
+		offset := 0
+		for i, src := range sources {
+			loaded, rejects, err := s.saveDataWithin(ctx, parts[i], converted[i], budget)
+			if err != nil {
+				return fmt.Errorf("%s: %w", src.name, err)
+			}
+			// rows rejected by DB spend budget of next files
+			budget -= len(rejects) - len(converted[i].rejects)
+
+			// rows are numbered through all files
+			for j := range rejects {
+				rejects[j].Row += offset
+			}
+			offset += converted[i].total
+
+			result.Rows += loaded
+			result.Rejects = append(result.Rejects, rejects...)
+			result.Files = append(result.Files, models.UnionFile{Name: src.name, Rows: loaded, Rejected: len(rejects), Missing: missing[i]})
+		}
+		result.Rejected = len(result.Rejects)
+
+		if len(result.Rejects) > 0 {
+			log.Warn("rows rejected", "table", table.QualifiedName(), "count", len(result.Rejects))
+			id, err := s.saveRejects(ctx, table, importID, opts, result.Rejects)
+			if err != nil {
+				return err
+			}
+			result.ImportID = id
+		}
+		return nil
+	})
+	if err != nil {
+		return models.UnionResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// go to DB (save profile), data is already loaded --> not fatal
+	if err := s.repo.Profile().Save(ctx, table); err != nil {
+		log.Warn("failed to save profile", slog.Any("err", err))
+	}
+
+	log.Debug("files processed successfully", "table", table.QualifiedName(), "files", len(sources), "rows", result.Rows)
+
+	return result, nil
+}
+
+// readUnionSources - open and parse files of union, entries of .zip archives become files, files without rows are skipped
+func (s *processorService) readUnionSources(ctx context.Context, files []string, open domain.FileOpener) ([]unionSource, error) {
+	var sources []unionSource
+
+	for _, name := range files {
+		f, err := open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		parsed, err := s.readUnionFile(ctx, name, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, parsed...)
+	}
+
+	sources = slices.DeleteFunc(sources, func(src unionSource) bool { return len(src.sheet.Rows) == 0 })
+	if len(sources) == 0 {
+		return nil, domain.ErrEmptyData
+	}
+
+	return sources, nil
+}
+
+// readUnionFile - parse file (first sheet of workbook) or every CSV and XLSX entry of archive (in order of names)
+func (s *processorService) readUnionFile(ctx context.Context, name string, r io.Reader) ([]unionSource, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	if ext != domain.ExtZIP {
+		source := newSourceReader(r)
+		sheet, err := s.parser.Parse(ctx, source, ext)
+		if err != nil {
+			return nil, fmt.Errorf("parsing of %s failed: %w", name, err)
+		}
+		checksum, size := source.finish()
+		return []unionSource{{name: name, sheet: sheet, checksum: checksum, size: size}}, nil
+	}
+
+	// zip needs random access --> archive is read to memory
+	// (body of HTTP request is limited by http_server.max_upload_size, CLI reads local files)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a zip archive: %w", name, domain.ErrUnsupportedExtension)
+	}
+
+	entries := slices.Clone(archive.File)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	// directories, hidden files (macOS metadata) and other files of archive are skipped
+	entries = slices.DeleteFunc(entries, func(entry *zip.File) bool {
+		entryExt := strings.ToLower(path.Ext(entry.Name))
+		return entry.FileInfo().IsDir() || strings.HasPrefix(path.Base(entry.Name), ".") || strings.HasPrefix(entry.Name, "__MACOSX/") ||
+			(entryExt != domain.ExtCSV && entryExt != domain.ExtXLSX)
+	})
+
+	// declared sizes of entries are checked before reading, reading is limited too as they can be forged (zip bomb)
+	limit := s.maxUnzippedSize
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	var declared uint64
+	for _, entry := range entries {
+		declared += entry.UncompressedSize64
+	}
+	if declared > uint64(limit) {
+		return nil, fmt.Errorf("%s: uncompressed size of files is above %d bytes: %w", name, limit, domain.ErrFileTooLarge)
+	}
+
+	var sources []unionSource
+	left := limit
+	for _, entry := range entries {
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s of %s: %w", entry.Name, name, err)
+		}
+		lr := &unzipReader{r: rc, left: left}
+		parsed, err := s.readUnionFile(ctx, entry.Name, lr)
+		rc.Close()
+		// limit error may be swallowed by parser or checksum of the rest of file
+		if lr.exceeded {
+			return nil, fmt.Errorf("%s: uncompressed size of files is above %d bytes: %w", name, limit, domain.ErrFileTooLarge)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		left = lr.left
+		sources = append(sources, parsed...)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%s has no CSV or XLSX files: %w", name, domain.ErrEmptyData)
+	}
+
+	return sources, nil
+}
+
+// unzipReader - reader of archive entry which fails when entries read so far exceed limit of uncompressed size
+type unzipReader struct {
+	r io.Reader
+	// left - bytes which can still be read
+	left     int64
+	exceeded bool
+}
+
+func (u *unzipReader) Read(p []byte) (int, error) {
+	if u.left <= 0 {
+		// entry may end exactly at limit
+		var probe [1]byte
+		if n, err := u.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		u.exceeded = true
+		return 0, domain.ErrFileTooLarge
+	}
+
+	if int64(len(p)) > u.left {
+		p = p[:u.left]
+	}
+	n, err := u.r.Read(p)
+	u.left -= int64(n)
+	return n, err
+}
+
+// unionChecksum - return checksum of union (names and checksums of files in order) and total size of files
+func unionChecksum(sources []unionSource) (string, int64) {
+	h := sha256.New()
+	var size int64
+	for _, src := range sources {
+		fmt.Fprintf(h, "%s\x00%s\n", src.name, src.checksum)
+		size += src.size
+	}
+	return hex.EncodeToString(h.Sum(nil)), size
+}
+
+// mergeSchemas - merge analyzed schemas of files (rows - number of data rows of every file):
+// columns are matched by names case-insensitively in order of appearance, types are widened.
+// mapping[i][j] - index of merged column for column j of file i.
+func mergeSchemas(tables []models.Table, rows []int) (models.Table, [][]int) {
+	merged := models.Table{Name: tables[0].Name, EnumMode: tables[0].EnumMode}
+	index := make(map[string][]int)
+	mapping := make([][]int, len(tables))
+	used := make([]map[int]bool, len(tables))
+
+	for i, table := range tables {
+		used[i] = make(map[int]bool, len(table.Columns))
+		mapping[i] = make([]int, len(table.Columns))
+
+		for j, col := range table.Columns {
+			key := strings.ToLower(col.Name)
+
+			// same name twice in one file (e.g. "Name" and "name") --> next merged column with this name
+			m := -1
+			for _, k := range index[key] {
+				if !used[i][k] {
+					m = k
+					break
+				}
+			}
+
+			if m < 0 {
+				// type changes are found in rows of one file, they are not kept in merged schema
+				col.Downgrades = nil
+				m = len(merged.Columns)
+				merged.Columns = append(merged.Columns, col)
+				index[key] = append(index[key], m)
+			} else {
+				merged.Columns[m] = mergeColumn(merged.Columns[m], col)
+			}
+
+			used[i][m] = true
+			mapping[i][j] = m
+		}
+	}
+
+	// columns absent in file are NULL in its rows
+	for i := range tables {
+		for m := range merged.Columns {
+			if !used[i][m] {
+				merged.Columns[m].Profile.NullCount += rows[i]
+			}
+		}
+	}
+
+	return merged, mapping
+}
+
+// mergeColumn - merge column b of next file into merged column a.
+// Profile of column of several files keeps only counters which can be combined.
+func mergeColumn(a, b models.Column) models.Column {
+	a.Type = widenType(a.Type, b.Type)
+
+	// enum stays enum only if values of every file are low-cardinality strings
+	if a.Type != models.DataTypeString || a.EnumValues == nil || b.EnumValues == nil {
+		a.EnumValues = nil
+	} else {
+		values := append(slices.Clone(a.EnumValues), b.EnumValues...)
+		sort.Strings(values)
+		a.EnumValues = slices.Compact(values)
+	}
+
+	a.Confidence = min(a.Confidence, b.Confidence)
+	a.Profile = models.ColumnProfile{
+		NullCount: a.Profile.NullCount + b.Profile.NullCount,
+		MaxLength: max(a.Profile.MaxLength, b.Profile.MaxLength),
+	}
+
+	return a
+}
+
+// widenType - return type which holds values of both types
+func widenType(a, b models.DataType) models.DataType {
+	switch {
+	case a == b:
+		return a
+	case a == models.DataTypeUnknown:
+		return b
+	case b == models.DataTypeUnknown:
+		return a
+	case isNumericType(a) && isNumericType(b):
+		return models.DataTypeFloat
+	default:
+		return models.DataTypeString
+	}
+}
+
+// unionPart - return table with columns of file (in file order, source column last), its data rows
+// and names of merged columns absent in file (columns left out of INSERT are NULL)
+func unionPart(table models.Table, mapping []int, src unionSource, sourceColumn string) (models.Table, [][]string, []string) {
+	part := models.Table{
+		Schema:   table.Schema,
+		Name:     table.Name,
+		EnumMode: table.EnumMode,
+		Columns:  make([]models.Column, 0, len(mapping)+1),
+	}
+
+	present := make(map[int]bool, len(mapping))
+	for _, m := range mapping {
+		part.Columns = append(part.Columns, table.Columns[m])
+		present[m] = true
+	}
+
+	merged := len(table.Columns)
+	if sourceColumn != "" {
+		merged--
+		part.Columns = append(part.Columns, table.Columns[merged])
+	}
+
+	var missing []string
+	for m := 0; m < merged; m++ {
+		if !present[m] {
+			missing = append(missing, table.Columns[m].Name)
+		}
+	}
+
+	width := len(mapping)
+	data := make([][]string, 0, len(src.sheet.Rows)-1)
+	for _, row := range src.sheet.Rows[1:] {
+		values := make([]string, len(part.Columns))
+		copy(values, row[:min(len(row), width)])
+		if sourceColumn != "" {
+			values[width] = src.name
+		}
+		data = append(data, values)
+	}
+
+	return part, data, missing
+}
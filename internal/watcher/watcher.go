@@ -0,0 +1,336 @@
+package watcher
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+)
+
+const (
+	// DoneDir - subdirectory of watched directory for imported files
+	DoneDir = "done"
+	// FailedDir - subdirectory of watched directory for failed files and their error sidecars
+	FailedDir = "failed"
+
+	// errorSuffix - suffix of error sidecar file of failed file
+	errorSuffix = ".error.json"
+)
+
+// dir - watched directory with upload options of its files
+type dir struct {
+	path string
+	opts models.UploadOptions
+}
+
+// fileState - size and modification time of file seen on previous scan
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher - import files dropped to watched directories (polling, works on network shares too)
+type Watcher struct {
+	processor domain.ProcessorService
+	dirs      []dir
+
+	pollInterval time.Duration
+	settleTime   time.Duration
+
+	// seen - files of previous scan, file is ready when it is unchanged between scans
+	seen map[string]fileState
+
+	log *slog.Logger
+}
+
+// NewWatcher - constructor for Watcher, validate settings of directories
+func NewWatcher(processor domain.ProcessorService, cfg config.WatcherCfg, log *slog.Logger) (*Watcher, error) {
+	const op = "watcher.NewWatcher"
+
+	if len(cfg.Dirs) == 0 {
+		return nil, fmt.Errorf("%s: no directories to watch", op)
+	}
+	if cfg.PollInterval <= 0 {
+		return nil, fmt.Errorf("%s: poll_interval must be positive, got %s", op, cfg.PollInterval)
+	}
+
+	dirs := make([]dir, len(cfg.Dirs))
+	for i, d := range cfg.Dirs {
+		opts, err := uploadOptions(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: directory %q: %w", op, d.Path, err)
+		}
+
+		info, err := os.Stat(d.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s: %q is not a directory", op, d.Path)
+		}
+
+		dirs[i] = dir{path: d.Path, opts: opts}
+	}
+
+	return &Watcher{
+		processor:    processor,
+		dirs:         dirs,
+		pollInterval: cfg.PollInterval,
+		settleTime:   cfg.SettleTime,
+		seen:         make(map[string]fileState),
+		log:          log,
+	}, nil
+}
+
+// Run - scan directories every poll interval until ctx is canceled
+func (w *Watcher) Run(ctx context.Context) error {
+	const op = "watcher.Watcher.Run"
+	log := w.log.With("op", op)
+
+	log.Info("watching directories", slog.Int("dirs", len(w.dirs)), slog.Duration("poll_interval", w.pollInterval))
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll - scan directories once and import ready files
+func (w *Watcher) Poll(ctx context.Context) {
+	const op = "watcher.Watcher.Poll"
+	log := w.log.With("op", op)
+
+	seen := make(map[string]fileState, len(w.seen))
+
+	for _, d := range w.dirs {
+		entries, err := os.ReadDir(d.path)
+		if err != nil {
+			log.Error("failed to read directory", slog.String("dir", d.path), slog.Any("err", err))
+			continue
+		}
+
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+			if !entry.Type().IsRegular() || !candidate(entry.Name()) {
+				continue
+			}
+
+			path := filepath.Join(d.path, entry.Name())
+			info, err := entry.Info()
+			if err != nil {
+				continue // removed while scanning
+			}
+
+			state := fileState{size: info.Size(), modTime: info.ModTime()}
+			if !w.ready(path, state) {
+				seen[path] = state
+				log.Debug("file is not ready", slog.String("file", path), slog.Int64("size", state.size))
+				continue
+			}
+
+			// file left in place (retry) is ready on next scan too
+			seen[path] = state
+			w.process(ctx, d, entry.Name())
+		}
+	}
+
+	w.seen = seen
+}
+
+// ready - report whether file is completely written: unchanged since previous scan and not modified for settle time
+func (w *Watcher) ready(path string, state fileState) bool {
+	prev, ok := w.seen[path]
+	if !ok || prev != state {
+		return false
+	}
+	return time.Since(state.modTime) >= w.settleTime
+}
+
+// process - import file and move it to done or failed directory
+func (w *Watcher) process(ctx context.Context, d dir, name string) {
+	const op = "watcher.Watcher.process"
+	log := w.log.With("op", op, slog.String("dir", d.path), slog.String("file", name))
+
+	path := filepath.Join(d.path, name)
+
+	result, err := w.upload(ctx, path, name, d.opts)
+	if ctx.Err() != nil {
+		// shutdown --> file stays in place and is imported on next start
+		log.Warn("import interrupted", slog.Any("err", err))
+		return
+	}
+
+	if err != nil && !fileError(err) {
+		// DB is down, disk is full, ... --> file stays in place and is retried on next poll
+		log.Warn("import failed, will be retried", slog.Any("err", err))
+		return
+	}
+
+	if err != nil {
+		log.Error("import failed", slog.Any("err", err))
+		if err := w.fail(d.path, name, err); err != nil {
+			log.Error("failed to move file", slog.Any("err", err))
+		}
+		return
+	}
+
+	log.Info("file imported",
+		slog.String("table", result.Table.QualifiedName()),
+		slog.Int("rows", result.Rows),
+		slog.Int("rejected", result.Rejected),
+		slog.Int64("import_id", result.ImportID),
+	)
+	if _, err := moveFile(path, filepath.Join(d.path, DoneDir)); err != nil {
+		log.Error("failed to move file", slog.Any("err", err))
+	}
+}
+
+func (w *Watcher) upload(ctx context.Context, path, name string, opts models.UploadOptions) (models.ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	defer f.Close()
+
+	return w.processor.UploadFile(ctx, name, f, strings.ToLower(filepath.Ext(name)), opts)
+}
+
+// fileErrors - errors of file content or directory settings, retry of such file fails again
+var fileErrors = []error{
+	domain.ErrUnsupportedExtension,
+	domain.ErrEmptyData,
+	domain.ErrNoColumns,
+	domain.ErrTooManyErrors,
+	domain.ErrSchemaDrift,
+	domain.ErrTableExists,
+	domain.ErrInvalidTableName,
+	domain.ErrInvalidColumnName,
+	domain.ErrInvalidSchema,
+	domain.ErrSchemaNotAllowed,
+	domain.ErrIdempotencyKeyReused,
+	// value rejected by DB (type, CHECK, NOT NULL, ...) in strict mode
+	domain.ErrRowRejected,
+	// malformed CSV, XLSX which is not zip archive
+	zip.ErrFormat,
+}
+
+// fileError - report whether import failed because of file (moved to failed directory),
+// not because of infrastructure
+func fileError(err error) bool {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return true
+	}
+	for _, target := range fileErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorSidecar - content of error sidecar file
+type errorSidecar struct {
+	File     string    `json:"file"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// fail - move file to failed directory and write error sidecar next to it
+func (w *Watcher) fail(dirPath, name string, importErr error) error {
+	moved, err := moveFile(filepath.Join(dirPath, name), filepath.Join(dirPath, FailedDir))
+	if err != nil {
+		return err
+	}
+
+	sidecar, err := json.MarshalIndent(errorSidecar{File: name, Error: importErr.Error(), FailedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(moved+errorSuffix, sidecar, 0o644)
+}
+
+// moveFile - move file to directory (created if absent), keep existing file with the same name; return new path
+func moveFile(path, toDir string) (string, error) {
+	if err := os.MkdirAll(toDir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(path)
+	target := filepath.Join(toDir, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(toDir, fmt.Sprintf("%s_%s%s",
+			strings.TrimSuffix(name, ext), time.Now().Format("20060102T150405.000"), ext))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// candidate - report whether file may be imported: supported extension, not hidden or lock file of office apps ("~$")
+func candidate(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case domain.ExtCSV, domain.ExtXLSX:
+		return true
+	default:
+		return false
+	}
+}
+
+// uploadOptions - return upload options of directory
+func uploadOptions(d config.WatchDirCfg) (models.UploadOptions, error) {
+	opts := models.UploadOptions{
+		Mode:       models.UploadMode(d.Mode),
+		Table:      d.Table,
+		Schema:     d.Schema,
+		OnConflict: models.ConflictPolicy(d.OnConflict),
+		Strict:     d.Strict,
+		Lineage:    d.Lineage,
+	}
+
+	switch opts.Mode {
+	case "", models.UploadModeReplace, models.UploadModeAppend:
+	default:
+		return models.UploadOptions{}, fmt.Errorf("invalid mode %q (use replace or append)", d.Mode)
+	}
+
+	switch opts.OnConflict {
+	case "", models.ConflictOverwrite, models.ConflictSuffix:
+	default:
+		return models.UploadOptions{}, fmt.Errorf("invalid on_conflict %q (use overwrite or suffix)", d.OnConflict)
+	}
+	if opts.Mode == models.UploadModeAppend && opts.OnConflict == models.ConflictSuffix {
+		return models.UploadOptions{}, errors.New("on_conflict suffix can't be used with append mode")
+	}
+
+	return opts, nil
+}
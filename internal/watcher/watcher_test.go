@@ -0,0 +1,183 @@
+package watcher_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/database/migrations"
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	"github.com/tmozzze/SQL_Converter/internal/domain"
+	"github.com/tmozzze/SQL_Converter/internal/domain/models"
+	"github.com/tmozzze/SQL_Converter/internal/repository/sqlite"
+	"github.com/tmozzze/SQL_Converter/internal/service"
+	"github.com/tmozzze/SQL_Converter/internal/watcher"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+// fakeProcessor - processor which fails bad.csv, db.csv fails while DB is down
+type fakeProcessor struct {
+	domain.ProcessorService
+	uploads []string
+	opts    []models.UploadOptions
+	dbDown  bool
+}
+
+func (p *fakeProcessor) UploadFile(_ context.Context, tableName string, file io.Reader, _ string, opts models.UploadOptions) (models.ImportResult, error) {
+	if _, err := io.ReadAll(file); err != nil {
+		return models.ImportResult{}, err
+	}
+	p.uploads = append(p.uploads, tableName)
+	p.opts = append(p.opts, opts)
+
+	if tableName == "bad.csv" {
+		return models.ImportResult{}, domain.ErrEmptyData
+	}
+	if tableName == "db.csv" && p.dbDown {
+		return models.ImportResult{}, errors.New("dial tcp: connection refused")
+	}
+	return models.ImportResult{Table: models.Table{Name: "sales"}, Rows: 1}, nil
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	inbox := t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	processor := &fakeProcessor{}
+
+	cfg := config.WatcherCfg{
+		Dirs:         []config.WatchDirCfg{{Path: inbox, Table: "sales", Mode: "append", Schema: "staging"}},
+		PollInterval: time.Second,
+	}
+	w, err := watcher.NewWatcher(processor, cfg, log)
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(inbox, name), []byte(content), 0o644))
+	}
+	write("good.csv", "id\n1")
+	write("bad.csv", "")
+	write("notes.txt", "not a table")
+	write("~$report.xlsx", "office lock file")
+
+	ctx := context.Background()
+
+	// first scan only remembers files (may be partially written)
+	w.Poll(ctx)
+	assert.Empty(t, processor.uploads)
+
+	// file is still being written
+	write("good.csv", "id\n1\n2")
+
+	w.Poll(ctx)
+	assert.Equal(t, []string{"bad.csv"}, processor.uploads)
+	assert.Equal(t, models.UploadOptions{Table: "sales", Mode: models.UploadModeAppend, Schema: "staging"}, processor.opts[0])
+
+	w.Poll(ctx)
+	assert.Equal(t, []string{"bad.csv", "good.csv"}, processor.uploads)
+
+	assert.FileExists(t, filepath.Join(inbox, watcher.DoneDir, "good.csv"))
+	assert.FileExists(t, filepath.Join(inbox, watcher.FailedDir, "bad.csv"))
+	assert.FileExists(t, filepath.Join(inbox, "notes.txt"))
+	assert.FileExists(t, filepath.Join(inbox, "~$report.xlsx"))
+	assert.NoFileExists(t, filepath.Join(inbox, "good.csv"))
+
+	sidecar, err := os.ReadFile(filepath.Join(inbox, watcher.FailedDir, "bad.csv.error.json"))
+	require.NoError(t, err)
+	var report struct {
+		File  string `json:"file"`
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(sidecar, &report))
+	assert.Equal(t, "bad.csv", report.File)
+	assert.Equal(t, domain.ErrEmptyData.Error(), report.Error)
+
+	// moved files are not imported again
+	w.Poll(ctx)
+	assert.Len(t, processor.uploads, 2)
+}
+
+func TestWatcher_PollRetriesInfrastructureErrors(t *testing.T) {
+	inbox := t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	processor := &fakeProcessor{dbDown: true}
+
+	cfg := config.WatcherCfg{Dirs: []config.WatchDirCfg{{Path: inbox}}, PollInterval: time.Second}
+	w, err := watcher.NewWatcher(processor, cfg, log)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(inbox, "db.csv"), []byte("id\n1"), 0o644))
+	ctx := context.Background()
+
+	w.Poll(ctx)
+	w.Poll(ctx)
+	assert.Equal(t, []string{"db.csv"}, processor.uploads)
+	assert.FileExists(t, filepath.Join(inbox, "db.csv"), "file stays in place")
+	assert.NoDirExists(t, filepath.Join(inbox, watcher.FailedDir))
+
+	processor.dbDown = false
+	w.Poll(ctx)
+	assert.Equal(t, []string{"db.csv", "db.csv"}, processor.uploads)
+	assert.FileExists(t, filepath.Join(inbox, watcher.DoneDir, "db.csv"))
+}
+
+func TestWatcher_PollFailsRowRejectedByDB(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	require.NoError(t, database.NewSQLiteMigrator(db, migrations.SQLite(), log).Up(ctx))
+
+	// value passes conversion, but not CHECK of existing table
+	_, err = db.Exec(`CREATE TABLE sales (id INTEGER CHECK (id > 0)) STRICT;`)
+	require.NoError(t, err)
+
+	processor := service.NewService(sqlite.NewRepository(db, log), config.AnalyzerCfg{}, config.ImportCfg{}, log).Processor()
+
+	inbox := t.TempDir()
+	cfg := config.WatcherCfg{
+		Dirs:         []config.WatchDirCfg{{Path: inbox, Table: "sales", Mode: "append"}},
+		PollInterval: time.Second,
+	}
+	w, err := watcher.NewWatcher(processor, cfg, log)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(inbox, "sales.csv"), []byte("id\n1\n-1"), 0o644))
+
+	w.Poll(ctx)
+	w.Poll(ctx)
+	assert.FileExists(t, filepath.Join(inbox, watcher.FailedDir, "sales.csv"), "retry fails again")
+	assert.NoFileExists(t, filepath.Join(inbox, "sales.csv"))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM sales;`).Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestNewWatcher_InvalidSettings(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := watcher.NewWatcher(&fakeProcessor{}, config.WatcherCfg{}, log)
+	assert.Error(t, err)
+
+	cfg := config.WatcherCfg{Dirs: []config.WatchDirCfg{{Path: t.TempDir(), Mode: "merge"}}, PollInterval: time.Second}
+	_, err = watcher.NewWatcher(&fakeProcessor{}, cfg, log)
+	assert.Error(t, err)
+
+	cfg = config.WatcherCfg{Dirs: []config.WatchDirCfg{{Path: t.TempDir()}}}
+	_, err = watcher.NewWatcher(&fakeProcessor{}, cfg, log)
+	assert.ErrorContains(t, err, "poll_interval must be positive")
+
+	cfg = config.WatcherCfg{Dirs: []config.WatchDirCfg{{Path: filepath.Join(t.TempDir(), "missing")}}, PollInterval: time.Second}
+	_, err = watcher.NewWatcher(&fakeProcessor{}, cfg, log)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
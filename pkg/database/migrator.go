@@ -0,0 +1,303 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationsLockKey - key of Postgres advisory lock held while migrating
+const migrationsLockKey int64 = 7_340_215_923
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// Migration - represent a migration file
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus - represent a migration with time of applying
+type MigrationStatus struct {
+	Migration
+	// AppliedAt - nil if migration is pending
+	AppliedAt *time.Time
+}
+
+// Migrator - apply goose-style SQL migrations (-- +goose Up / -- +goose Down)
+type Migrator struct {
+	db   *sql.DB
+	fsys fs.FS
+	// sqlite - DB is SQLite (no advisory lock, SQLite types in version table)
+	sqlite bool
+	log    *slog.Logger
+}
+
+// NewMigrator - constructor for Migrator of PostgreSQL
+func NewMigrator(db *sql.DB, fsys fs.FS, log *slog.Logger) *Migrator {
+	return &Migrator{db: db, fsys: fsys, log: log}
+}
+
+// NewSQLiteMigrator - constructor for Migrator of SQLite
+func NewSQLiteMigrator(db *sql.DB, fsys fs.FS, log *slog.Logger) *Migrator {
+	return &Migrator{db: db, fsys: fsys, sqlite: true, log: log}
+}
+
+// Up - apply all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	const op = "database.Migrator.Up"
+	log := m.log.With("op", op)
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		migrations, applied, err := m.load(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, mg := range migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+
+			insert := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+			if err := m.apply(ctx, conn, mg.Up, insert, mg.Version, mg.Name); err != nil {
+				return fmt.Errorf("%s: migration %d_%s: %w", op, mg.Version, mg.Name, err)
+			}
+			log.Info("migration applied", "version", mg.Version, "name", mg.Name)
+		}
+
+		return nil
+	})
+}
+
+// Down - roll back the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	const op = "database.Migrator.Down"
+	log := m.log.With("op", op)
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		migrations, applied, err := m.load(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			mg := migrations[i]
+			if _, ok := applied[mg.Version]; !ok {
+				continue
+			}
+
+			remove := `DELETE FROM schema_migrations WHERE version = $1;`
+			if err := m.apply(ctx, conn, mg.Down, remove, mg.Version); err != nil {
+				return fmt.Errorf("%s: migration %d_%s: %w", op, mg.Version, mg.Name, err)
+			}
+			log.Info("migration rolled back", "version", mg.Version, "name", mg.Name)
+			return nil
+		}
+
+		log.Info("no migrations to roll back")
+		return nil
+	})
+}
+
+// Status - return all migrations with time of applying
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	const op = "database.Migrator.Status"
+
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		migrations, applied, err := m.load(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		statuses = make([]MigrationStatus, len(migrations))
+		for i, mg := range migrations {
+			statuses[i] = MigrationStatus{Migration: mg}
+			if at, ok := applied[mg.Version]; ok {
+				statuses[i].AppliedAt = &at
+			}
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock - run fn on dedicated connection holding advisory lock (replicas migrate one by one)
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// SQLite file is written by one process, transaction of migration locks it
+	if m.sqlite {
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, migrationsLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1);`, migrationsLockKey); err != nil {
+			m.log.Warn("failed to release migrations lock", slog.Any("err", err))
+		}
+	}()
+
+	return fn(conn)
+}
+
+// load - read migration files and applied versions
+func (m *Migrator) load(ctx context.Context, conn *sql.Conn) ([]Migration, map[int64]time.Time, error) {
+	migrations, err := ReadMigrations(m.fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	createQuery := `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
+	if m.sqlite {
+		createQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+	}
+	if _, err := conn.ExecContext(ctx, createQuery); err != nil {
+		return nil, nil, fmt.Errorf("failed to create version table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read applied versions: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan applied version: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read applied versions: %w", err)
+	}
+
+	return migrations, applied, nil
+}
+
+// apply - execute migration statements and version query in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, statements string, versionQuery string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			m.log.Debug("rollback failed", slog.Any("err", err))
+		}
+	}()
+
+	if strings.TrimSpace(statements) != "" {
+		if _, err := tx.ExecContext(ctx, statements); err != nil {
+			return fmt.Errorf("failed to execute statements: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, versionQuery, args...); err != nil {
+		return fmt.Errorf("failed to update version table: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ReadMigrations - read migration files (<version>_<name>.sql) sorted by version
+func ReadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations dir: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+
+	for _, e := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", e.Name(), err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, e.Name())
+		}
+		seen[version] = e.Name()
+
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+
+		up, down := parseMigration(string(content))
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Up: up, Down: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// parseMigration - split file into Up and Down sections
+func parseMigration(content string) (string, string) {
+	var up, down strings.Builder
+	var current *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		annotation := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(annotation, "-- +goose Up"):
+			current = &up
+			continue
+		case strings.HasPrefix(annotation, "-- +goose Down"):
+			current = &down
+			continue
+		case strings.HasPrefix(annotation, "-- +goose"):
+			// StatementBegin/StatementEnd - sections are executed as a whole
+			continue
+		}
+
+		if current != nil {
+			current.WriteString(line)
+			current.WriteByte('\n')
+		}
+	}
+
+	return up.String(), down.String()
+}
@@ -0,0 +1,64 @@
+package database_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmozzze/SQL_Converter/database/migrations"
+	"github.com/tmozzze/SQL_Converter/pkg/database"
+)
+
+var testMigrations = fstest.MapFS{
+	"00002_second.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INT);\n-- +goose Down\nDROP TABLE b;\n")},
+	"00001_first.sql":  {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE a (id INT);\n-- +goose StatementEnd\n\n-- +goose Down\nDROP TABLE a;\n")},
+	"README.md":        {Data: []byte("not a migration")},
+}
+
+func TestReadMigrations(t *testing.T) {
+	list, err := database.ReadMigrations(testMigrations)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	assert.Equal(t, int64(1), list[0].Version)
+	assert.Equal(t, "first", list[0].Name)
+	assert.Equal(t, "CREATE TABLE a (id INT);\n\n", list[0].Up)
+	assert.Equal(t, "DROP TABLE a;\n", list[0].Down)
+	assert.Equal(t, int64(2), list[1].Version)
+
+	// embedded migrations are valid
+	embedded, err := database.ReadMigrations(migrations.FS)
+	require.NoError(t, err)
+	assert.NotEmpty(t, embedded)
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	migrator := database.NewMigrator(db, testMigrations, log)
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\);`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations;`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(int64(1), time.Now()))
+
+	// only second migration is pending
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE b \(id INT\);`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(2), "second").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\);`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, migrator.Up(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
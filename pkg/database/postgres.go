@@ -14,8 +14,16 @@ import (
 func NewPostgresDB(cfg config.PostgresCfg) (*sql.DB, error) {
 	const op = "database.NewPostgresDB"
 
-	// DSN
-	dsn := cfg.DSN()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return OpenPostgres(cfg.DSN(), cfg)
+}
+
+// OpenPostgres - initialize a new DB connection to PostgreSQL by DSN (URL or key=value pairs) with pool settings of cfg
+func OpenPostgres(dsn string, cfg config.PostgresCfg) (*sql.DB, error) {
+	const op = "database.OpenPostgres"
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
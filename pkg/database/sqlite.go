@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tmozzze/SQL_Converter/internal/config"
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteDB - open (create if missing) SQLite DB file
+func NewSQLiteDB(cfg config.SQLiteCfg) (*sql.DB, error) {
+	const op = "database.NewSQLiteDB"
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("%s: failed to create directory of %s: %w", op, cfg.Path, err)
+	}
+
+	return OpenSQLite(cfg.Path)
+}
+
+// OpenSQLite - open SQLite DB by path (":memory:" - in-memory DB)
+func OpenSQLite(path string) (*sql.DB, error) {
+	const op = "database.OpenSQLite"
+
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open db %s: %w", op, path, err)
+	}
+
+	// SQLite has one writer, transactions of repository are serialized on one connection
+	// (in-memory DB also exists only in its connection)
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to ping database: %w", op, err)
+	}
+
+	return db, nil
+}